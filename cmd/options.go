@@ -34,12 +34,61 @@ Commands:
 
   version      Print version information
   commit       Create a commit using the configured commitizen flow
-  changelog    Generate a CHANGELOG.md from commit logs
+  changelog    Generate a CHANGELOG at the repository root from commit logs
+      Usage: changelog [options]
+      Options for changelog:
+          --incremental  Keep the existing CHANGELOG and only add the new releases
+                         above the newest documented one; the unreleased section
+                         is regenerated
+          --format <f>   Output format: markdown (default), keepachangelog, json,
+                         html or asciidoc
+          --template <path>  Render with a Go text/template file instead of a format
+          --from <rev>   Leave out the commits reachable from rev (e.g. v1.0.0)
+          --to <rev>     Last revision to include (default: HEAD)
+          --since <date> Leave out the commits older than date (e.g. 2025-01-01)
+          --unreleased   Only write the commits after the newest release
+          --output <path>  Write to path instead of CHANGELOG
+          --stdout       Write to standard output instead of a file
+          --include-types <list>  Only keep these comma-separated types
+          --exclude-types <list>  Leave out these types (default from the config:
+                         chore,style,WIP); an empty list keeps every type
+          --include-merges  Keep merge commits
+          --exclude-author <regex>  Leave out commits whose "Name <email>" matches
+          --exclude-header <regex>  Leave out commits whose header matches
+          --show-reverted  Keep commits reverted in the same release
+          --no-filter    Ignore the filters in the config
+          --description  Write the first paragraph of each commit body under its entry
+          --check        Do not write the file; print a diff and exit with 1 when it
+                         differs from the generated changelog
   bump         Bump the version automatically
+  scan         Scan for secrets outside the commit flow
+      Usage: scan [--staged | --range A..B | --all-history | <paths>...]
+      Options for scan:
+          --staged       Scan the lines added by the staged changes (default)
+          --full-file    With --staged, scan whole staged files
+          --range <A..B> Scan the lines added by each commit in a revision range
+          --all-history  Scan the lines added by every commit reachable from any ref
+          <paths>...     Scan working-tree files and directories
+          --format <f>   Output format: text (default), json or sarif
+          --no-baseline  Also report findings recorded in the baseline file
+      Exits with 0 when nothing is found, 1 on findings and 2 on errors.
+
+  secrets      Manage the secret scanner
+      Subcommands:
+          baseline       Scan all tracked files and write the findings to the
+                         baseline file (.gommitizen-secrets.json) so they are no
+                         longer reported
+
   lint         Lint commit messages
       Options for lint:
           --all, -a      Lint all commit messages in the repository
           --current, -c  Lint only the current (latest) commit message
+          --max-count, -n <n>  With --all, lint at most n commits
+          --progress     With --all, print progress to stderr
+          --file <path>  Lint the message in a file (for the commit-msg hook)
+          --fix          Apply automatic fixes: with --current, show a diff and amend HEAD
+                         on confirmation; with --file, rewrite the file in place
+          --yes, -y      With --fix --current, amend without asking
           [commit-message]  Optionally, provide a commit message directly
 
   help         Display this help message`)
@@ -120,9 +169,14 @@ func VersionCommand() {
 
 // LintOptions holds the options for the lint command.
 type LintOptions struct {
-    All     bool
-    Current bool
-    Message string
+    All      bool
+    Current  bool
+    Message  string
+    File     string
+    MaxCount int
+    Progress bool
+    Fix      bool
+    Yes      bool
 }
 
 // ParseLintOptions parses the lint command flags and returns a LintOptions struct.
@@ -132,22 +186,222 @@ func ParseLintOptions(args []string) (LintOptions, error) {
     allShort := lf.Bool("a", false, "Lint all commit messages (short)")
     currentLong := lf.Bool("current", false, "Lint the current commit message")
     currentShort := lf.Bool("c", false, "Lint the current commit message (short)")
+    maxCountLong := lf.Int("max-count", 0, "Lint at most this many commits with --all")
+    maxCountShort := lf.Int("n", 0, "Lint at most this many commits with --all (short)")
+    progress := lf.Bool("progress", false, "Show progress while linting with --all")
+    file := lf.String("file", "", "Lint the commit message stored in a file (e.g. from the commit-msg hook)")
+    fix := lf.Bool("fix", false, "Apply the automatic fixes")
+    yesLong := lf.Bool("yes", false, "Amend HEAD with --fix --current without asking")
+    yesShort := lf.Bool("y", false, "Amend HEAD with --fix --current without asking (short)")
     lf.Parse(args)
 
     opts := LintOptions{
-        All:     *allLong || *allShort,
-        Current: *currentLong || *currentShort,
+        All:      *allLong || *allShort,
+        Current:  *currentLong || *currentShort,
+        File:     *file,
+        MaxCount: *maxCountLong,
+        Progress: *progress,
+        Fix:      *fix,
+        Yes:      *yesLong || *yesShort,
+    }
+    if *maxCountShort > 0 {
+        opts.MaxCount = *maxCountShort
+    }
+    if opts.MaxCount < 0 {
+        return opts, fmt.Errorf("--max-count must not be negative")
     }
 
     if lf.NArg() > 0 {
         opts.Message = lf.Arg(0)
     }
+    if opts.Fix && opts.All {
+        return opts, fmt.Errorf("--fix works with --current, --file or a message, not --all")
+    }
+
+    return opts, nil
+}
+
+// ChangelogOptions holds the options for the changelog command.
+type ChangelogOptions struct {
+    Incremental bool
+    Format      string
+    Template    string
+    From        string
+    To          string
+    Since       string
+    Unreleased  bool
+    Output      string
+    Stdout      bool
+
+    NoFilter       bool
+    IncludeTypes   []string // Nil when the flag is not given
+    ExcludeTypes   []string // Nil when the flag is not given
+    IncludeMerges  bool
+    ExcludeAuthors []string
+    ExcludeHeaders []string
+    ShowReverted   bool
+
+    Description bool
+    Check       bool
+}
+
+// ParseChangelogOptions parses the changelog command flags and returns a ChangelogOptions struct.
+func ParseChangelogOptions(args []string) (ChangelogOptions, error) {
+    cf := flag.NewFlagSet("changelog", flag.ExitOnError)
+    incremental := cf.Bool("incremental", false, "Only add the releases that are not in CHANGELOG yet")
+    format := cf.String("format", "markdown", "Output format: markdown, keepachangelog, json, html or asciidoc")
+    tmpl := cf.String("template", "", "Render the changelog with a Go text/template file")
+    from := cf.String("from", "", "Leave out the commits reachable from this revision")
+    to := cf.String("to", "", "Last revision to include (default: HEAD)")
+    since := cf.String("since", "", "Leave out the commits older than this date")
+    unreleased := cf.Bool("unreleased", false, "Only write the commits after the newest release")
+    output := cf.String("output", "", "Write to this file instead of CHANGELOG at the repository root")
+    stdout := cf.Bool("stdout", false, "Write to standard output instead of a file")
+    noFilter := cf.Bool("no-filter", false, "Ignore the filters in the config")
+    includeTypes := cf.String("include-types", "", "Only keep these comma-separated types")
+    excludeTypes := cf.String("exclude-types", "", "Leave out these comma-separated types")
+    includeMerges := cf.Bool("include-merges", false, "Keep merge commits")
+    showReverted := cf.Bool("show-reverted", false, "Keep reverted commits and their reverts")
+    description := cf.Bool("description", false, "Write the first body paragraph under each entry")
+    check := cf.Bool("check", false, "Exit with an error and a diff when the file is not up to date")
+    var excludeAuthors, excludeHeaders []string
+    cf.Func("exclude-author", "Leave out commits whose \"Name <email>\" matches this regex (repeatable)", func(v string) error {
+        excludeAuthors = append(excludeAuthors, v)
+        return nil
+    })
+    cf.Func("exclude-header", "Leave out commits whose header matches this regex (repeatable)", func(v string) error {
+        excludeHeaders = append(excludeHeaders, v)
+        return nil
+    })
+    cf.Parse(args)
+
+    opts := ChangelogOptions{
+        Incremental: *incremental,
+        Format:      *format,
+        Template:    *tmpl,
+        From:        *from,
+        To:          *to,
+        Since:       *since,
+        Unreleased:  *unreleased,
+        Output:      *output,
+        Stdout:      *stdout,
+
+        NoFilter:       *noFilter,
+        IncludeMerges:  *includeMerges,
+        ExcludeAuthors: excludeAuthors,
+        ExcludeHeaders: excludeHeaders,
+        ShowReverted:   *showReverted,
+
+        Description: *description,
+        Check:       *check,
+    }
+    // An empty list given on the command line clears the list of the config.
+    cf.Visit(func(f *flag.Flag) {
+        switch f.Name {
+        case "include-types":
+            opts.IncludeTypes = splitList(*includeTypes)
+        case "exclude-types":
+            opts.ExcludeTypes = splitList(*excludeTypes)
+        }
+    })
+    if cf.NArg() > 0 {
+        return opts, fmt.Errorf("unexpected argument %q", cf.Arg(0))
+    }
+    switch opts.Format {
+    case "markdown", "keepachangelog", "json", "html", "asciidoc":
+    default:
+        return opts, fmt.Errorf("unknown output format %q (expected markdown, keepachangelog, json, html or asciidoc)", opts.Format)
+    }
+    if opts.Template != "" && opts.Format != "markdown" {
+        return opts, fmt.Errorf("use either --format or --template")
+    }
+    if opts.Incremental && (opts.Template != "" || (opts.Format != "markdown" && opts.Format != "keepachangelog")) {
+        return opts, fmt.Errorf("--incremental only works with the markdown and keepachangelog formats")
+    }
+    if opts.Incremental && opts.Unreleased {
+        return opts, fmt.Errorf("use either --incremental or --unreleased")
+    }
+    if opts.Output != "" && opts.Stdout {
+        return opts, fmt.Errorf("use either --output or --stdout")
+    }
+    if opts.Check && opts.Stdout {
+        return opts, fmt.Errorf("use either --check or --stdout")
+    }
+    return opts, nil
+}
+
+// ScanOptions holds the options for the scan command.
+type ScanOptions struct {
+    Staged     bool
+    FullFile   bool
+    Range      string
+    AllHistory bool
+    Paths      []string
+    Format     string
+    NoBaseline bool
+}
+
+// ParseScanOptions parses the scan command flags and returns a ScanOptions struct.
+func ParseScanOptions(args []string) (ScanOptions, error) {
+    sf := flag.NewFlagSet("scan", flag.ExitOnError)
+    staged := sf.Bool("staged", false, "Scan the staged changes")
+    fullFile := sf.Bool("full-file", false, "Scan whole staged files instead of only the added lines")
+    revRange := sf.String("range", "", "Scan the commits in a revision range (e.g. main..HEAD)")
+    allHistory := sf.Bool("all-history", false, "Scan every commit reachable from any ref")
+    format := sf.String("format", "text", "Output format: text, json or sarif")
+    noBaseline := sf.Bool("no-baseline", false, "Also report findings recorded in the baseline file")
+
+    // Allow flags after the paths, as in "scan src --format json".
+    var paths []string
+    for sf.Parse(args); sf.NArg() > 0; sf.Parse(args) {
+        paths = append(paths, sf.Arg(0))
+        args = sf.Args()[1:]
+    }
+
+    opts := ScanOptions{
+        Staged:     *staged,
+        FullFile:   *fullFile,
+        Range:      *revRange,
+        AllHistory: *allHistory,
+        Paths:      paths,
+        Format:     *format,
+        NoBaseline: *noBaseline,
+    }
+
+    sources := 0
+    for _, set := range []bool{opts.Staged, opts.Range != "", opts.AllHistory, len(opts.Paths) > 0} {
+        if set {
+            sources++
+        }
+    }
+    if sources > 1 {
+        return opts, fmt.Errorf("use only one of --staged, --range, --all-history or paths")
+    }
+    if opts.FullFile && sources == 1 && !opts.Staged {
+        return opts, fmt.Errorf("--full-file only applies to staged changes")
+    }
+    switch opts.Format {
+    case "text", "json", "sarif":
+    default:
+        return opts, fmt.Errorf("unknown output format %q (expected text, json or sarif)", opts.Format)
+    }
 
     return opts, nil
 }
 
 // Helpers
 
+// splitList splits a comma-separated flag value, dropping empty items. It never returns nil.
+func splitList(value string) []string {
+    items := []string{}
+    for _, item := range strings.Split(value, ",") {
+        if item = strings.TrimSpace(item); item != "" {
+            items = append(items, item)
+        }
+    }
+    return items
+}
+
 // parsePathFlag parses the --path or -p flag from args.
 func parsePathFlag(args []string) string {
     fs := flag.NewFlagSet("path", flag.ExitOnError)
@@ -1,110 +1,503 @@
 package internal
 
 import (
-    "bytes"
     "fmt"
     "os"
     "os/exec"
     "path/filepath"
+    "regexp"
+    "sort"
     "strings"
-    "sync"
+
+    "gommitizen/internal/utils"
 )
 
 // commitEntry represents a parsed commit.
 type commitEntry struct {
-    hash    string
-    date    string
-    author  string
-    ctype   string
-    subject string
+    fullHash    string
+    hash        string
+    merge       bool // More than one parent
+    date        string
+    author      string
+    email       string
+    header      string   // First line of the message
+    ctype       string   // Type from a conventional header; empty otherwise
+    scope       string   // Scope from a conventional header
+    subject     string   // Header without the type and scope prefix
+    body        string   // Message after the header, trimmed
+    description string   // First paragraph of the body, unless it is the footer
+    breaking    []string // Descriptions of the breaking changes
+    refs        []string // Issues from the Closes, Fixes, Resolves and Refs footers
+    reverts     string   // Hash from a "This reverts commit <hash>." line
 }
 
-// GenerateChangelog runs "git log" to extract commit messages (including commit date and author),
-// processes them concurrently, groups them by commit type, and writes the results to CHANGELOG.md.
-func GenerateChangelog() error {
-    // Run git log with a custom format: hash | date | author | subject.
-    // Using a delimiter (|) makes parsing easier.
+// changelogSection is a titled group of entries in the changelog.
+type changelogSection struct {
+    title   string
+    entries []commitEntry
+}
+
+// changelogRelease is the part of the changelog for one release tag, or the unreleased commits.
+type changelogRelease struct {
+    version  string // Tag name; empty for unreleased commits
+    date     string // Tag date as YYYY-MM-DD
+    revision string // Tag name, or the last revision for unreleased commits
+    previous string // Tag of the previous release, or the start of the range; empty for the first one
+    sections []changelogSection
+}
+
+// versionTag is a release tag and the commit it points to.
+type versionTag struct {
+    name   string
+    commit string
+    date   string
+}
+
+// changelogRange limits the history covered by the changelog.
+type changelogRange struct {
+    from  string // Leave out the commits reachable from this revision; empty for the whole history
+    to    string // Last revision; empty for HEAD
+    since string // Leave out the commits older than this date, in any format "git log --since" accepts
+}
+
+// head returns the last revision of the range.
+func (r changelogRange) head() string {
+    if r.to == "" {
+        return "HEAD"
+    }
+    return r.to
+}
+
+// readVersionTags lists the release tags within the range, newest first by ancestry, so that a
+// release candidate tagged before a final version comes after it whatever their names.
+// When a commit has several release tags, only the highest version is kept.
+func readVersionTags(cfg ChangelogConfig, rng changelogRange) ([]versionTag, error) {
+    pattern, err := regexp.Compile(cfg.TagPattern)
+    if err != nil {
+        return nil, fmt.Errorf("invalid changelog tag pattern %q: %v", cfg.TagPattern, err)
+    }
+    // %(*objectname) is the commit of an annotated tag; lightweight tags point to it directly.
+    // versionsort.suffix ranks "v1.0.0-rc1" below "v1.0.0" among the tags of one commit.
+    args := []string{"-c", "versionsort.suffix=-", "for-each-ref", "--merged", rng.head(), "--sort=-v:refname",
+        "--format=%(refname:strip=2)%09%(*objectname)%09%(objectname)%09%(creatordate:short)"}
+    if rng.from != "" {
+        args = append(args, "--no-merged", rng.from)
+    }
+    cmd := exec.Command("git", append(args, "refs/tags")...)
+    out, err := cmd.Output()
+    if err != nil {
+        if exitErr, ok := err.(*exec.ExitError); ok && len(exitErr.Stderr) > 0 {
+            return nil, fmt.Errorf("failed to list tags: %s", strings.TrimSpace(string(exitErr.Stderr)))
+        }
+        return nil, fmt.Errorf("failed to list tags: %v", err)
+    }
+
+    var tags []versionTag
+    tagged := make(map[string]bool) // Commits that already have a release tag
+    for _, line := range strings.Split(string(out), "\n") {
+        fields := strings.Split(line, "\t")
+        if len(fields) != 4 {
+            continue
+        }
+        name := fields[0]
+        if !strings.HasPrefix(name, cfg.TagPrefix) || !pattern.MatchString(strings.TrimPrefix(name, cfg.TagPrefix)) {
+            continue
+        }
+        commit := fields[1]
+        if commit == "" {
+            commit = fields[2]
+        }
+        if tagged[commit] {
+            continue
+        }
+        tagged[commit] = true
+        tags = append(tags, versionTag{name: name, commit: commit, date: fields[3]})
+    }
+    if len(tags) < 2 {
+        return tags, nil
+    }
+
+    // Order by ancestry: as "git rev-list --topo-order" lists the tagged commits, newest first.
+    out, err = exec.Command("git", "rev-list", "--topo-order", rng.head()).Output()
+    if err != nil {
+        return nil, fmt.Errorf("failed to order tags: %v", err)
+    }
+    position := make(map[string]int)
+    for i, commit := range strings.Fields(string(out)) {
+        position[commit] = i
+    }
+    sort.SliceStable(tags, func(i, j int) bool {
+        return position[tags[i].commit] < position[tags[j].commit]
+    })
+    return tags, nil
+}
+
+// readChangelogCommits runs "git log" on revisions, such as "v1.0.0..v1.1.0", and parses the commits, newest first.
+func readChangelogCommits(revs []string, since string) ([]commitEntry, error) {
+    // Run git log with a custom format: hashes, parents, date, author and the full message, separated
+    // by unit separators, with a NUL after each commit so that messages may span several lines.
     // --date=iso will output the commit date in ISO 8601 format (which includes the timezone offset).
-    cmd := exec.Command("git", "log", "--pretty=format:%h|%ad|%an|%s", "--date=iso")
-    out, err := cmd.CombinedOutput()
+    args := []string{"log", "--pretty=format:%H%x1f%h%x1f%P%x1f%ad%x1f%an%x1f%ae%x1f%B%x00", "--date=iso"}
+    if since != "" {
+        args = append(args, "--since="+since)
+    }
+    args = append(append(args, revs...), "--")
+    cmd := exec.Command("git", args...)
+    out, err := cmd.Output()
     if err != nil {
-        return fmt.Errorf("failed to run git log: %v", err)
+        if exitErr, ok := err.(*exec.ExitError); ok && len(exitErr.Stderr) > 0 {
+            return nil, fmt.Errorf("failed to run git log: %s", strings.TrimSpace(string(exitErr.Stderr)))
+        }
+        return nil, fmt.Errorf("failed to run git log: %v", err)
     }
 
-    // Split the log output into individual commit lines.
-    lines := strings.Split(string(out), "\n")
-    if len(lines) == 0 {
-        return fmt.Errorf("no commits found")
+    var entries []commitEntry
+    for _, record := range strings.Split(string(out), "\x00") {
+        record = strings.TrimLeft(record, "\n")
+        if record == "" {
+            continue
+        }
+        parts := strings.SplitN(record, "\x1f", 7)
+        if len(parts) < 7 {
+            // Skip malformed records.
+            continue
+        }
+        e := commitEntry{
+            fullHash: parts[0],
+            hash:     parts[1],
+            merge:    len(strings.Fields(parts[2])) > 1,
+            date:     strings.TrimSpace(parts[3]),
+            author:   strings.TrimSpace(parts[4]),
+            email:    strings.TrimSpace(parts[5]),
+        }
+        parseCommitMessage(&e, parts[6])
+        entries = append(entries, e)
     }
+    return entries, nil
+}
 
-    // Channel to collect parsed commit entries.
-    commitCh := make(chan commitEntry, len(lines))
-    var wg sync.WaitGroup
+// refTokens are the footer tokens (in lower case) whose values are issue references.
+var refTokens = map[string]bool{
+    "closes": true, "close": true, "closed": true,
+    "fixes": true, "fix": true, "fixed": true,
+    "resolves": true, "resolve": true, "resolved": true,
+    "refs": true, "ref": true, "references": true,
+}
 
-    // Process each commit line concurrently.
+// firstParagraph returns the first paragraph of the lines, joined with newlines.
+func firstParagraph(lines []string) string {
+    var para []string
     for _, line := range lines {
-        line = strings.TrimSpace(line)
-        if line == "" {
+        if strings.TrimSpace(line) == "" {
+            if len(para) > 0 {
+                break
+            }
             continue
         }
-        wg.Add(1)
-        go func(l string) {
-            defer wg.Done()
-            // Expect the format: "<hash>|<date>|<author>|<subject>"
-            parts := strings.SplitN(l, "|", 4)
-            if len(parts) < 4 {
-                // Skip malformed lines.
-                return
+        para = append(para, strings.TrimRight(line, " \t"))
+    }
+    return strings.Join(para, "\n")
+}
+
+// revertRegexp matches the line "git revert" adds to the message.
+var revertRegexp = regexp.MustCompile(`(?m)^This reverts commit ([0-9a-f]{7,40})\b`)
+
+// parseCommitMessage parses the header and footers of a commit message into the entry.
+// Headers that do not follow the convention are kept whole and have no type.
+func parseCommitMessage(e *commitEntry, message string) {
+    lines := strings.Split(strings.TrimRight(message, "\n"), "\n")
+    e.header = strings.TrimSpace(lines[0])
+    e.subject = e.header
+    e.body = strings.TrimSpace(strings.Join(lines[1:], "\n"))
+    if m := revertRegexp.FindStringSubmatch(e.body); m != nil {
+        e.reverts = m[1]
+    }
+
+    footer := footerStart(lines)
+    e.description = firstParagraph(lines[1:footer])
+    trailers, _ := parseFooter(lines)
+    for _, t := range trailers {
+        if refTokens[strings.ToLower(t.token)] {
+            e.refs = append(e.refs, splitIssueRefs(t.value)...)
+        }
+    }
+
+    header, ok := parseConventionalHeader(lines[0])
+    if !ok {
+        return
+    }
+    e.ctype, e.scope, e.subject = header.typ, header.scope, header.subject
+
+    e.breaking = breakingNotes(lines)
+    if header.breaking && len(e.breaking) == 0 {
+        // A "!" without a footer: the subject describes the breaking change.
+        e.breaking = []string{e.subject}
+    }
+}
+
+// breakingNotes returns the text of each "BREAKING CHANGE:" footer, including the lines
+// that follow it up to the next trailer.
+func breakingNotes(lines []string) []string {
+    var notes []string
+    inNote := false
+    for _, line := range lines[footerStart(lines):] {
+        if t, ok := parseTrailer(line); ok && !strings.HasPrefix(line, " ") && !strings.HasPrefix(line, "\t") {
+            inNote = isBreakingToken(t.token)
+            if inNote {
+                notes = append(notes, t.value)
             }
-            hash := parts[0]
-            date := strings.TrimSpace(parts[1])
-            author := strings.TrimSpace(parts[2])
-            subjectLine := parts[3]
-
-            // Extract commit type from subject (if a colon exists).
-            ctype := "unknown"
-            if colonIdx := strings.Index(subjectLine, ":"); colonIdx != -1 {
-                ctype = strings.TrimSpace(subjectLine[:colonIdx])
+            continue
+        }
+        if line = strings.TrimSpace(line); inNote && line != "" {
+            notes[len(notes)-1] = strings.TrimSpace(notes[len(notes)-1] + "\n" + line)
+        }
+    }
+    return notes
+}
+
+// readReleases splits the history at the release tags: the commits after the newest tag are
+// unreleased, and each tag gets the commits since the previous one.
+// With a start date, releases left without commits are omitted. The filter applies to each release
+// separately, so only reverts within a release hide the reverted commits.
+func readReleases(cfg ChangelogConfig, rng changelogRange, filter *changelogFilter) ([]changelogRelease, error) {
+    tags, err := readVersionTags(cfg, rng)
+    if err != nil {
+        return nil, err
+    }
+
+    type releaseRange struct {
+        changelogRelease
+        revs []string
+    }
+    // Each release excludes the commits of every older tag, so that releases never overlap,
+    // even when tags sit on different branches.
+    excludeFrom := func(i int) []string {
+        var revs []string
+        for _, t := range tags[i:] {
+            revs = append(revs, "^"+t.commit)
+        }
+        return revs
+    }
+    ranges := []releaseRange{{changelogRelease{revision: rng.head()}, append([]string{rng.head()}, excludeFrom(0)...)}}
+    if len(tags) > 0 {
+        ranges[0].previous = tags[0].name
+    }
+    for i, t := range tags {
+        r := releaseRange{changelogRelease{version: t.name, date: t.date, revision: t.name}, append([]string{t.commit}, excludeFrom(i+1)...)}
+        if i+1 < len(tags) {
+            r.previous = tags[i+1].name
+        }
+        ranges = append(ranges, r)
+    }
+    // The oldest release in a range starting at a revision is compared with that revision.
+    if rng.from != "" {
+        ranges[len(ranges)-1].previous = rng.from
+    }
+
+    var releases []changelogRelease
+    total := 0
+    for _, r := range ranges {
+        revs := r.revs
+        if rng.from != "" {
+            revs = append(revs, "^"+rng.from)
+        }
+        entries, err := readChangelogCommits(revs, rng.since)
+        if err != nil {
+            return nil, err
+        }
+        total += len(entries)
+        if len(entries) == 0 && rng.since != "" {
+            continue
+        }
+        entries = filter.apply(entries)
+        if len(entries) == 0 && r.version == "" {
+            continue
+        }
+        release := r.changelogRelease
+        release.sections = groupSections(cfg, entries)
+        releases = append(releases, release)
+    }
+    if total == 0 {
+        return nil, fmt.Errorf("no commits found")
+    }
+    return releases, nil
+}
+
+// groupSections sorts entries into the configured sections, in the configured order.
+// Entries keep their history order within a section. Types that are not configured go to
+// the "other" section, placed last, or are dropped when it has no title. Empty sections are omitted.
+// Breaking changes are also listed first, in their own section, with the footer text as subject.
+func groupSections(cfg ChangelogConfig, entries []commitEntry) []changelogSection {
+    var sections []changelogSection
+    if cfg.BreakingTitle != "" {
+        breaking := changelogSection{title: cfg.BreakingTitle}
+        for _, e := range entries {
+            for _, note := range e.breaking {
+                b := e
+                b.subject, b.description = note, ""
+                breaking.entries = append(breaking.entries, b)
             }
+        }
+        sections = append(sections, breaking)
+    }
+    index := make(map[string]int) // Section title -> position in sections
+    sectionFor := make(map[string]string, len(cfg.Sections))
+    for _, sc := range cfg.Sections {
+        sectionFor[sc.Type] = sc.Title
+        if _, ok := index[sc.Title]; !ok {
+            index[sc.Title] = len(sections)
+            sections = append(sections, changelogSection{title: sc.Title})
+        }
+    }
+    var other []commitEntry
 
-            commitCh <- commitEntry{
-                hash:    hash,
-                date:    date,
-                author:  author,
-                ctype:   ctype,
-                subject: subjectLine,
+    for _, e := range entries {
+        title, ok := sectionFor[e.ctype]
+        if !ok {
+            if cfg.Other != "" {
+                other = append(other, e)
             }
-        }(line)
+            continue
+        }
+        i := index[title]
+        sections[i].entries = append(sections[i].entries, e)
+    }
+
+    // A configured section with the same title as "other" takes the unlisted types too.
+    if i, ok := index[cfg.Other]; ok && cfg.Other != "" {
+        sections[i].entries = append(sections[i].entries, other...)
+        other = nil
+    }
+    if len(other) > 0 {
+        sections = append(sections, changelogSection{title: cfg.Other, entries: other})
+    }
+
+    var nonEmpty []changelogSection
+    for _, sc := range sections {
+        if len(sc.entries) > 0 {
+            nonEmpty = append(nonEmpty, sc)
+        }
     }
+    return nonEmpty
+}
+
+// ChangelogOptions holds the options for GenerateChangelog.
+type ChangelogOptions struct {
+    Incremental bool   // Only add the releases newer than those already in CHANGELOG
+    Format      string // Built-in output format; see changelogFormats
+    Template    string // Path of a text/template file used instead of a built-in format
+    From        string // Leave out the commits reachable from this revision
+    To          string // Last revision instead of HEAD
+    Since       string // Leave out the commits older than this date
+    Unreleased  bool   // Only write the commits after the newest release
+    Output      string // File to write instead of CHANGELOG at the repository root
+    Stdout      bool   // Write to standard output instead of a file
 
-    // Wait for all goroutines to finish processing.
-    wg.Wait()
-    close(commitCh)
+    NoFilter       bool     // Ignore the filters in the config
+    IncludeTypes   []string // Replace the included types of the config when not nil
+    ExcludeTypes   []string // Replace the excluded types of the config when not nil
+    IncludeMerges  bool     // Keep merge commits
+    ExcludeAuthors []string // Author regexes, in addition to the config
+    ExcludeHeaders []string // Header regexes, in addition to the config
+    ShowReverted   bool     // Keep reverted commits and their reverts
 
-    // Group commits by type.
-    groups := make(map[string][]commitEntry)
-    for entry := range commitCh {
-        groups[entry.ctype] = append(groups[entry.ctype], entry)
+    Description bool // Write the first body paragraph under each entry, whatever the config says
+
+    Check bool // Compare with the file instead of writing it; an error lists the differences
+}
+
+// GenerateChangelog runs "git log" to extract commit messages (including commit date and author),
+// splits them by release tag, groups them into the configured sections and writes the results
+// in the chosen format to CHANGELOG at the repository root, to another file or to standard output.
+// The output only changes when the history, the tags or the config do.
+// In incremental mode, an existing changelog keeps everything below its unreleased section.
+func GenerateChangelog(opts ChangelogOptions) error {
+    cfg := loadConfigOrDefault().Changelog
+    cfg.Description = cfg.Description || opts.Description
+    if !strings.Contains(cfg.ReleaseHeading, "{version}") {
+        return fmt.Errorf("changelog.releaseHeading must contain {version}")
+    }
+    filterCfg := cfg.Filter
+    if opts.NoFilter {
+        filterCfg = ChangelogFilterConfig{}
+    }
+    if opts.IncludeTypes != nil {
+        filterCfg.IncludeTypes = opts.IncludeTypes
+    }
+    if opts.ExcludeTypes != nil {
+        filterCfg.ExcludeTypes = opts.ExcludeTypes
+    }
+    filterCfg.ExcludeMerges = filterCfg.ExcludeMerges && !opts.IncludeMerges
+    filterCfg.HideReverted = filterCfg.HideReverted && !opts.ShowReverted
+    filterCfg.ExcludeAuthors = append(filterCfg.ExcludeAuthors, opts.ExcludeAuthors...)
+    filterCfg.ExcludeHeaders = append(filterCfg.ExcludeHeaders, opts.ExcludeHeaders...)
+    filter, err := newChangelogFilter(filterCfg)
+    if err != nil {
+        return err
     }
 
-    // Build the changelog content.
-    var buf bytes.Buffer
-    buf.WriteString("# Changelog\n\n")
-    for typ, entries := range groups {
-        buf.WriteString(fmt.Sprintf("## %s\n\n", typ))
-        for _, e := range entries {
-            // Include hash, date, author, and subject for each commit.
-            buf.WriteString(fmt.Sprintf("- [%s] %s by %s: %s\n", e.hash, e.date, e.author, e.subject))
+    releases, err := readReleases(cfg, changelogRange{from: opts.From, to: opts.To, since: opts.Since}, filter)
+    if err != nil {
+        return err
+    }
+    if opts.Unreleased {
+        if len(releases) == 0 || releases[0].version != "" {
+            return fmt.Errorf("no unreleased commits found")
         }
-        buf.WriteString("\n")
+        releases = releases[:1]
     }
 
-    // Write the content to CHANGELOG.
-    changelogPath := filepath.Join(".", "CHANGELOG")
-    if err := os.WriteFile(changelogPath, buf.Bytes(), 0644); err != nil {
-        return fmt.Errorf("failed to write CHANGELOG: %v", err)
+    changelogPath := opts.Output
+    if changelogPath == "" {
+        root, err := gitRoot()
+        if err != nil {
+            return err
+        }
+        changelogPath = filepath.Join(root, "CHANGELOG")
+    }
+    links, err := readRepoLinks(cfg)
+    if err != nil {
+        return err
+    }
+    content, err := formatChangelog(cfg, releases, links, opts.Format, opts.Template)
+    if err != nil {
+        return err
+    }
+    if opts.Incremental {
+        style, ok := markdownStyleFor(cfg, opts.Format, links)
+        if !ok || opts.Template != "" {
+            return fmt.Errorf("incremental updates only work with the markdown and keepachangelog formats")
+        }
+        existing, err := os.ReadFile(changelogPath)
+        switch {
+        case err == nil:
+            if content, err = updateChangelog(style, cfg.Marker, existing, releases); err != nil {
+                return err
+            }
+        case !os.IsNotExist(err):
+            return fmt.Errorf("failed to read %s: %v", changelogPath, err)
+        }
+    }
+
+    if opts.Check {
+        existing, err := os.ReadFile(changelogPath)
+        if err != nil && !os.IsNotExist(err) {
+            return fmt.Errorf("failed to read %s: %v", changelogPath, err)
+        }
+        if diff := utils.UnifiedDiff(changelogPath, changelogPath+" (generated)", string(existing), string(content)); diff != "" {
+            fmt.Print(utils.StdoutDiff(diff))
+            return fmt.Errorf("%s is out of date; run \"git-cz changelog\" to update it", changelogPath)
+        }
+        fmt.Println("Changelog is up to date:", changelogPath)
+        return nil
+    }
+    if opts.Stdout {
+        _, err := os.Stdout.Write(content)
+        return err
+    }
+    if err := os.WriteFile(changelogPath, content, 0644); err != nil {
+        return fmt.Errorf("failed to write %s: %v", changelogPath, err)
     }
     fmt.Println("Changelog generated in", changelogPath)
     return nil
 }
-
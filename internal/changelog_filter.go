@@ -0,0 +1,105 @@
+package internal
+
+import (
+    "fmt"
+    "regexp"
+    "strings"
+)
+
+// changelogFilter decides which commits are left out of the changelog.
+type changelogFilter struct {
+    includeTypes  map[string]bool // Nil keeps every type
+    excludeTypes  map[string]bool
+    excludeMerges bool
+    authors       []*regexp.Regexp
+    headers       []*regexp.Regexp
+    hideReverted  bool
+}
+
+// newChangelogFilter compiles the filter config.
+func newChangelogFilter(cfg ChangelogFilterConfig) (*changelogFilter, error) {
+    f := &changelogFilter{
+        excludeTypes:  make(map[string]bool),
+        excludeMerges: cfg.ExcludeMerges,
+        hideReverted:  cfg.HideReverted,
+    }
+    if len(cfg.IncludeTypes) > 0 {
+        f.includeTypes = make(map[string]bool)
+        for _, t := range cfg.IncludeTypes {
+            f.includeTypes[t] = true
+        }
+    }
+    for _, t := range cfg.ExcludeTypes {
+        f.excludeTypes[t] = true
+    }
+
+    for _, pat := range cfg.ExcludeAuthors {
+        re, err := regexp.Compile(pat)
+        if err != nil {
+            return nil, fmt.Errorf("invalid changelog author pattern %q: %v", pat, err)
+        }
+        f.authors = append(f.authors, re)
+    }
+    for _, pat := range cfg.ExcludeHeaders {
+        re, err := regexp.Compile(pat)
+        if err != nil {
+            return nil, fmt.Errorf("invalid changelog header pattern %q: %v", pat, err)
+        }
+        f.headers = append(f.headers, re)
+    }
+    return f, nil
+}
+
+// apply returns the entries of one release that pass the filter, in order.
+func (f *changelogFilter) apply(entries []commitEntry) []commitEntry {
+    hidden := make(map[string]bool) // Full hashes of reverted commits and of their reverts
+    if f.hideReverted {
+        for _, revert := range entries {
+            if revert.reverts == "" {
+                continue
+            }
+            for _, e := range entries {
+                if strings.HasPrefix(e.fullHash, revert.reverts) {
+                    hidden[e.fullHash] = true
+                    hidden[revert.fullHash] = true
+                }
+            }
+        }
+    }
+
+    var kept []commitEntry
+    for _, e := range entries {
+        if !hidden[e.fullHash] && f.keeps(e) {
+            kept = append(kept, e)
+        }
+    }
+    return kept
+}
+
+// keeps reports whether a commit passes the type, merge, author and header filters.
+// The type filters never hide breaking changes.
+func (f *changelogFilter) keeps(e commitEntry) bool {
+    if f.excludeMerges && (e.merge || strings.HasPrefix(e.header, "Merge ")) {
+        return false
+    }
+    if len(e.breaking) == 0 {
+        if f.includeTypes != nil && !f.includeTypes[e.ctype] {
+            return false
+        }
+        if f.excludeTypes[e.ctype] {
+            return false
+        }
+    }
+    person := fmt.Sprintf("%s <%s>", e.author, e.email)
+    for _, re := range f.authors {
+        if re.MatchString(person) {
+            return false
+        }
+    }
+    for _, re := range f.headers {
+        if re.MatchString(e.header) {
+            return false
+        }
+    }
+    return true
+}
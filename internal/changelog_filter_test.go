@@ -0,0 +1,98 @@
+package internal
+
+import (
+    "reflect"
+    "testing"
+)
+
+// entrySubjects returns the subjects of the entries, in order.
+func entrySubjects(entries []commitEntry) []string {
+    var subjects []string
+    for _, e := range entries {
+        subjects = append(subjects, e.subject)
+    }
+    return subjects
+}
+
+func TestChangelogFilterApply(t *testing.T) {
+    entries := []commitEntry{
+        {fullHash: "aaaa1111aaaa", subject: "revert login", ctype: "revert", header: "revert: add login", reverts: "bbbb222"},
+        {fullHash: "bbbb2222bbbb", subject: "add login", ctype: "feat", header: "feat: add login"},
+        {fullHash: "cccc3333cccc", subject: "bump deps", ctype: "chore", header: "chore: bump deps", author: "renovate[bot]", email: "bot@example.com"},
+        {fullHash: "dddd4444dddd", subject: "Merge branch 'topic'", header: "Merge branch 'topic'", merge: true},
+        {fullHash: "eeee5555eeee", subject: "drop v1 api", ctype: "chore", header: "chore!: drop v1 api", breaking: []string{"drop v1 api"}},
+        {fullHash: "ffff6666ffff", subject: "fix typo", ctype: "docs", header: "docs: fix typo"},
+        {fullHash: "9999aaaa9999", subject: "revert something older", ctype: "revert", header: "revert: older", reverts: "1234567"},
+        {fullHash: "8888bbbb8888", subject: "tidy", ctype: "style", header: "style: tidy [skip changelog]"},
+    }
+
+    tests := []struct {
+        name string
+        cfg  ChangelogFilterConfig
+        want []string
+    }{
+        {
+            name: "no filter",
+            want: []string{"revert login", "add login", "bump deps", "Merge branch 'topic'", "drop v1 api", "fix typo", "revert something older", "tidy"},
+        },
+        {
+            name: "reverted commits are hidden with their revert",
+            cfg:  ChangelogFilterConfig{HideReverted: true},
+            want: []string{"bump deps", "Merge branch 'topic'", "drop v1 api", "fix typo", "revert something older", "tidy"},
+        },
+        {
+            name: "excluded types keep breaking changes",
+            cfg:  ChangelogFilterConfig{ExcludeTypes: []string{"chore", "style"}},
+            want: []string{"revert login", "add login", "Merge branch 'topic'", "drop v1 api", "fix typo", "revert something older"},
+        },
+        {
+            name: "included types keep breaking changes",
+            cfg:  ChangelogFilterConfig{IncludeTypes: []string{"feat"}},
+            want: []string{"add login", "drop v1 api"},
+        },
+        {
+            name: "merges, authors and headers",
+            cfg: ChangelogFilterConfig{
+                ExcludeMerges:  true,
+                ExcludeAuthors: []string{`\[bot\]`},
+                ExcludeHeaders: []string{`\[skip changelog\]`},
+            },
+            want: []string{"revert login", "add login", "drop v1 api", "fix typo", "revert something older"},
+        },
+        {
+            name: "authors are matched with their email",
+            cfg:  ChangelogFilterConfig{ExcludeAuthors: []string{`<bot@example\.com>$`}},
+            want: []string{"revert login", "add login", "Merge branch 'topic'", "drop v1 api", "fix typo", "revert something older", "tidy"},
+        },
+    }
+
+    for _, tt := range tests {
+        t.Run(tt.name, func(t *testing.T) {
+            f, err := newChangelogFilter(tt.cfg)
+            if err != nil {
+                t.Fatalf("newChangelogFilter() error = %v", err)
+            }
+            if got := entrySubjects(f.apply(entries)); !reflect.DeepEqual(got, tt.want) {
+                t.Errorf("apply() = %q, want %q", got, tt.want)
+            }
+        })
+    }
+}
+
+func TestNewChangelogFilterInvalidPatterns(t *testing.T) {
+    tests := []struct {
+        name string
+        cfg  ChangelogFilterConfig
+    }{
+        {"author", ChangelogFilterConfig{ExcludeAuthors: []string{"("}}},
+        {"header", ChangelogFilterConfig{ExcludeHeaders: []string{"["}}},
+    }
+
+    for _, tt := range tests {
+        t.Run(tt.name, func(t *testing.T) {
+            if _, err := newChangelogFilter(tt.cfg); err == nil {
+                t.Error("newChangelogFilter() error = nil, want an error")
+            }
+        })
+    }
+}
@@ -0,0 +1,305 @@
+package internal
+
+import (
+    "bytes"
+    "encoding/json"
+    "fmt"
+    htmltemplate "html/template"
+    "io"
+    "os"
+    "path/filepath"
+    "strings"
+    "text/template"
+)
+
+// changelogFormats are the built-in output formats of the changelog command.
+var changelogFormats = []string{"markdown", "keepachangelog", "json", "html", "asciidoc"}
+
+// formatChangelog renders the releases in a built-in format, or with the template file when one is given.
+// Commits, issues and releases are linked when links is not nil.
+func formatChangelog(cfg ChangelogConfig, releases []changelogRelease, links *repoLinks, format, templatePath string) ([]byte, error) {
+    if templatePath != "" {
+        text, err := os.ReadFile(templatePath)
+        if err != nil {
+            return nil, fmt.Errorf("failed to read changelog template: %v", err)
+        }
+        tmpl, err := template.New(filepath.Base(templatePath)).Funcs(changelogTemplateFuncs).Parse(string(text))
+        if err != nil {
+            return nil, fmt.Errorf("failed to parse changelog template: %v", err)
+        }
+        return executeChangelogTemplate(tmpl, newChangelogData(cfg, releases, links))
+    }
+
+    if style, ok := markdownStyleFor(cfg, format, links); ok {
+        var buf bytes.Buffer
+        buf.WriteString(style.title)
+        style.renderReleases(&buf, releases)
+        return buf.Bytes(), nil
+    }
+    switch format {
+    case "json":
+        out, err := json.MarshalIndent(newChangelogData(cfg, releases, links), "", "  ")
+        if err != nil {
+            return nil, err
+        }
+        return append(out, '\n'), nil
+    case "html":
+        return executeChangelogTemplate(htmlChangelogTemplate, newChangelogData(cfg, releases, links))
+    case "asciidoc":
+        return executeChangelogTemplate(asciidocChangelogTemplate, newChangelogData(cfg, releases, links))
+    }
+    return nil, fmt.Errorf("unknown changelog format %q (expected %s)", format, strings.Join(changelogFormats, ", "))
+}
+
+// =======================
+// Markdown
+// =======================
+
+// markdownStyle is the layout of a Markdown changelog. Incremental updates use the same
+// headings to find the releases already written.
+type markdownStyle struct {
+    title             string // Written before the releases
+    releaseHeading    string // With {version} and {date} placeholders
+    unreleasedHeading string
+    entry             func(e commitEntry) string
+    links             *repoLinks // Nil without links
+    description       bool       // Write the first body paragraph under each entry
+}
+
+// markdownStyleFor returns the style of a Markdown-based format; ok is false for other formats.
+func markdownStyleFor(cfg ChangelogConfig, format string, links *repoLinks) (style markdownStyle, ok bool) {
+    switch format {
+    case "", "markdown":
+        return markdownStyle{
+            title:             "# Changelog\n\n",
+            releaseHeading:    cfg.ReleaseHeading,
+            unreleasedHeading: cfg.UnreleasedHeading,
+            entry: func(e commitEntry) string {
+                // Include hash, date, author, scope and subject for each commit.
+                hash := "[" + e.hash + "]"
+                if links != nil {
+                    hash = markdownLink(e.hash, links.commit(e.hash))
+                }
+                return fmt.Sprintf("%s %s by %s: %s", hash, e.date, e.author, links.linkIssues(scopedSubject(e)+refsSuffix(e)))
+            },
+            links:       links,
+            description: cfg.Description,
+        }, true
+    case "keepachangelog":
+        // See https://keepachangelog.com/en/1.1.0/.
+        return markdownStyle{
+            title: "# Changelog\n\nAll notable changes to this project will be documented in this file.\n\n" +
+                "The format is based on [Keep a Changelog](https://keepachangelog.com/en/1.1.0/).\n\n",
+            releaseHeading:    "## [{version}] - {date}",
+            unreleasedHeading: "## [Unreleased]",
+            entry: func(e commitEntry) string {
+                hash := e.hash
+                if links != nil {
+                    hash = markdownLink(e.hash, links.commit(e.hash))
+                }
+                return fmt.Sprintf("%s (%s)", links.linkIssues(scopedSubject(e)+refsSuffix(e)), hash)
+            },
+            links:       links,
+            description: cfg.Description,
+        }, true
+    }
+    return markdownStyle{}, false
+}
+
+// markdownLink returns a Markdown inline link.
+func markdownLink(text, url string) string {
+    return "[" + text + "](" + url + ")"
+}
+
+// refsSuffix lists the references of an entry that its subject does not mention, as in " (#12, #14)".
+func refsSuffix(e commitEntry) string {
+    refs := unmentioned(e.refs, e.subject)
+    if len(refs) == 0 {
+        return ""
+    }
+    return " (" + strings.Join(refs, ", ") + ")"
+}
+
+// unmentioned returns the references that do not occur in the text.
+func unmentioned(refs []string, text string) []string {
+    var out []string
+    for _, ref := range refs {
+        if !strings.Contains(text, ref) {
+            out = append(out, ref)
+        }
+    }
+    return out
+}
+
+// scopedSubject returns the subject with the scope in bold in front of it, as in "**api:** add pagination".
+func scopedSubject(e commitEntry) string {
+    if e.scope == "" {
+        return e.subject
+    }
+    return fmt.Sprintf("**%s:** %s", e.scope, e.subject)
+}
+
+// renderReleases writes the releases with their headings and a "###" subsection per type,
+// each followed by a blank line.
+func (s markdownStyle) renderReleases(buf *bytes.Buffer, releases []changelogRelease) {
+    for _, r := range releases {
+        buf.WriteString(s.heading(r) + "\n\n")
+        for _, sc := range r.sections {
+            buf.WriteString(fmt.Sprintf("### %s\n\n", sc.title))
+            for _, e := range sc.entries {
+                // Continuation lines of multi-line breaking change notes stay in the list item.
+                buf.WriteString("- " + strings.ReplaceAll(s.entry(e), "\n", "\n  ") + "\n")
+                if s.description && e.description != "" {
+                    // Indented under the list item, after a blank line, so it stays a separate paragraph.
+                    buf.WriteString("\n  " + strings.ReplaceAll(s.links.linkIssues(e.description), "\n", "\n  ") + "\n\n")
+                }
+            }
+            buf.WriteString("\n")
+        }
+    }
+}
+
+// heading fills in the release heading for a release. With links, the version, or the text of
+// the unreleased heading, links to the changes since the previous release; a version already
+// in brackets, as in "[{version}]", becomes the link text.
+func (s markdownStyle) heading(r changelogRelease) string {
+    link := s.links.releaseLink(r)
+    if r.version == "" {
+        if link == "" {
+            return s.unreleasedHeading
+        }
+        text := strings.TrimLeft(s.unreleasedHeading, "# ")
+        level := s.unreleasedHeading[:len(s.unreleasedHeading)-len(text)]
+        return level + markdownLink(strings.TrimSuffix(strings.TrimPrefix(text, "["), "]"), link)
+    }
+    heading := s.releaseHeading
+    version := r.version
+    if link != "" {
+        heading = strings.Replace(heading, "[{version}]", "{version}", 1)
+        version = markdownLink(r.version, link)
+    }
+    return strings.NewReplacer("{version}", version, "{date}", r.date).Replace(heading)
+}
+
+// =======================
+// Templates
+// =======================
+
+// changelogData is the data model given to changelog templates and written by the json format.
+type changelogData struct {
+    Releases []releaseData `json:"releases"` // Newest first
+}
+
+// releaseData is one release; Version is empty for the unreleased commits.
+type releaseData struct {
+    Version    string        `json:"version"`
+    Date       string        `json:"date,omitempty"`       // Tag date as YYYY-MM-DD
+    CompareURL string        `json:"compareUrl,omitempty"` // Changes since the previous release
+    Sections   []sectionData `json:"sections"`
+}
+
+// sectionData is a titled group of entries within a release.
+type sectionData struct {
+    Title   string      `json:"title"`
+    Entries []entryData `json:"entries"`
+}
+
+// entryData is one commit of a section.
+type entryData struct {
+    Hash    string   `json:"hash"`
+    URL     string   `json:"url,omitempty"` // Link to the commit
+    Author  string   `json:"author"`
+    Date    string   `json:"date"`
+    Type    string   `json:"type,omitempty"`
+    Scope   string   `json:"scope,omitempty"`
+    Subject string   `json:"subject"`
+    Body    string   `json:"body,omitempty"`
+    Refs    []string `json:"refs,omitempty"`
+
+    Description string `json:"description,omitempty"` // First body paragraph, when descriptions are enabled
+}
+
+// newChangelogData converts the releases to the template data model.
+func newChangelogData(cfg ChangelogConfig, releases []changelogRelease, links *repoLinks) changelogData {
+    data := changelogData{Releases: make([]releaseData, 0, len(releases))}
+    for _, r := range releases {
+        rd := releaseData{
+            Version:    r.version,
+            Date:       r.date,
+            CompareURL: links.releaseLink(r),
+            Sections:   make([]sectionData, 0, len(r.sections)),
+        }
+        for _, sc := range r.sections {
+            sd := sectionData{Title: sc.title, Entries: make([]entryData, 0, len(sc.entries))}
+            for _, e := range sc.entries {
+                ed := entryData{
+                    Hash:    e.hash,
+                    URL:     links.commitLink(e.hash),
+                    Author:  e.author,
+                    Date:    e.date,
+                    Type:    e.ctype,
+                    Scope:   e.scope,
+                    Subject: e.subject,
+                    Body:    e.body,
+                    Refs:    e.refs,
+                }
+                if cfg.Description {
+                    ed.Description = e.description
+                }
+                sd.Entries = append(sd.Entries, ed)
+            }
+            rd.Sections = append(rd.Sections, sd)
+        }
+        data.Releases = append(data.Releases, rd)
+    }
+    return data
+}
+
+// changelogTemplateFuncs are the functions available in changelog templates besides the built-in ones.
+var changelogTemplateFuncs = map[string]interface{}{
+    "join":        strings.Join,
+    "unmentioned": unmentioned,
+}
+
+// changelogTemplate is a text or HTML template.
+type changelogTemplate interface {
+    Execute(w io.Writer, data interface{}) error
+}
+
+// executeChangelogTemplate renders a template with the data model.
+func executeChangelogTemplate(tmpl changelogTemplate, data changelogData) ([]byte, error) {
+    var buf bytes.Buffer
+    if err := tmpl.Execute(&buf, data); err != nil {
+        return nil, fmt.Errorf("failed to render changelog template: %v", err)
+    }
+    return buf.Bytes(), nil
+}
+
+// htmlChangelogTemplate renders an HTML fragment; html/template escapes the commit text.
+var htmlChangelogTemplate = htmltemplate.Must(htmltemplate.New("html").Funcs(changelogTemplateFuncs).Parse(`<h1>Changelog</h1>
+{{range .Releases}}
+<h2>{{if .CompareURL}}<a href="{{.CompareURL}}">{{end}}{{or .Version "Unreleased"}}{{if .CompareURL}}</a>{{end}}{{if .Version}} ({{.Date}}){{end}}</h2>
+{{range .Sections}}
+<h3>{{.Title}}</h3>
+<ul>
+{{- range .Entries}}
+  <li>{{if .URL}}<a href="{{.URL}}"><code>{{.Hash}}</code></a>{{else}}<code>{{.Hash}}</code>{{end}} {{if .Scope}}<strong>{{.Scope}}:</strong> {{end}}{{.Subject}}{{with unmentioned .Refs .Subject}} ({{join . ", "}}){{end}}
+    {{- with .Description}}<p>{{.}}</p>{{end}}</li>
+{{- end}}
+</ul>
+{{end}}{{end}}`))
+
+// asciidocChangelogTemplate renders an AsciiDoc document.
+var asciidocChangelogTemplate = template.Must(template.New("asciidoc").Funcs(changelogTemplateFuncs).Parse(`= Changelog
+{{range .Releases}}
+== {{if .CompareURL}}{{.CompareURL}}[{{or .Version "Unreleased"}}]{{else}}{{or .Version "Unreleased"}}{{end}}{{if .Version}} ({{.Date}}){{end}}
+{{range .Sections}}
+=== {{.Title}}
+{{range .Entries}}
+* {{if .URL}}{{.URL}}[` + "`{{.Hash}}`" + `]{{else}}` + "`{{.Hash}}`" + `{{end}} {{if .Scope}}*{{.Scope}}:* {{end}}{{.Subject}}{{with unmentioned .Refs .Subject}} ({{join . ", "}}){{end}}
+{{- with .Description}}
++
+{{.}}
+{{- end}}
+{{- end}}
+{{end}}{{end}}`))
@@ -0,0 +1,160 @@
+package internal
+
+import (
+    "fmt"
+    "net/url"
+    "os/exec"
+    "regexp"
+    "strings"
+)
+
+// repoLinks builds web links to commits, issues and comparisons of a hosted repository.
+type repoLinks struct {
+    base string // Web URL of the repository, without a trailing slash
+    host string // github, gitlab, bitbucket or gitea
+}
+
+// changelogHosts are the hosting services whose link layouts are known.
+var changelogHosts = []string{"github", "gitlab", "bitbucket", "gitea"}
+
+// readRepoLinks returns the links for the configured repository URL, or for the origin remote
+// when none is configured. It returns nil when links are disabled or there is no usable remote.
+func readRepoLinks(cfg ChangelogConfig) (*repoLinks, error) {
+    if !cfg.Links {
+        return nil, nil
+    }
+    base := strings.TrimSuffix(cfg.RepositoryURL, "/")
+    if base == "" {
+        out, err := exec.Command("git", "remote", "get-url", "origin").Output()
+        if err != nil {
+            return nil, nil // No origin remote: no links.
+        }
+        var ok bool
+        if base, ok = parseRemoteURL(strings.TrimSpace(string(out))); !ok {
+            return nil, nil
+        }
+    }
+
+    host := cfg.RepositoryHost
+    if host == "" {
+        host = guessHost(base)
+    }
+    for _, h := range changelogHosts {
+        if host == h {
+            return &repoLinks{base: base, host: host}, nil
+        }
+    }
+    return nil, fmt.Errorf("unknown changelog.repositoryHost %q (expected %s)", host, strings.Join(changelogHosts, ", "))
+}
+
+// scpRemoteRegexp matches scp-like remotes such as "git@github.com:owner/repo.git".
+var scpRemoteRegexp = regexp.MustCompile(`^(?:[^@/]+@)?([^:/]+):(.+)$`)
+
+// parseRemoteURL converts a remote URL in ssh, scp-like, git or http(s) form to the web URL
+// of the repository. Credentials and ports of ssh remotes are dropped.
+func parseRemoteURL(remote string) (string, bool) {
+    scheme, host, path := "https", "", ""
+    if u, err := url.Parse(remote); err == nil && u.Scheme != "" && u.Host != "" {
+        switch u.Scheme {
+        case "http", "https":
+            scheme, host = u.Scheme, u.Host
+        case "ssh", "git", "git+ssh", "ssh+git":
+            host = u.Hostname()
+        default:
+            return "", false
+        }
+        path = u.Path
+    } else if strings.Contains(remote, "://") {
+        return "", false
+    } else if m := scpRemoteRegexp.FindStringSubmatch(remote); m != nil {
+        host, path = m[1], m[2]
+    } else {
+        return "", false
+    }
+
+    path = strings.TrimSuffix(strings.Trim(path, "/"), ".git")
+    if host == "" || !strings.Contains(path, "/") {
+        return "", false
+    }
+    return scheme + "://" + host + "/" + path, true
+}
+
+// guessHost guesses the hosting service from the host name. Unknown hosts get the GitHub
+// layout, which Gitea and most of its forks share.
+func guessHost(base string) string {
+    u, err := url.Parse(base)
+    if err != nil {
+        return "github"
+    }
+    name := strings.ToLower(u.Hostname())
+    switch {
+    case strings.Contains(name, "gitlab"):
+        return "gitlab"
+    case strings.Contains(name, "bitbucket"):
+        return "bitbucket"
+    case strings.Contains(name, "gitea"), strings.Contains(name, "codeberg"):
+        return "gitea"
+    }
+    return "github"
+}
+
+// commit returns the link to a commit.
+func (l *repoLinks) commit(hash string) string {
+    switch l.host {
+    case "gitlab":
+        return l.base + "/-/commit/" + hash
+    case "bitbucket":
+        return l.base + "/commits/" + hash
+    }
+    return l.base + "/commit/" + hash
+}
+
+// issue returns the link to an issue by number.
+func (l *repoLinks) issue(number string) string {
+    if l.host == "gitlab" {
+        return l.base + "/-/issues/" + number
+    }
+    return l.base + "/issues/" + number
+}
+
+// compare returns the link to the changes between two revisions.
+func (l *repoLinks) compare(from, to string) string {
+    switch l.host {
+    case "gitlab":
+        return l.base + "/-/compare/" + from + "..." + to
+    case "bitbucket":
+        return l.base + "/branches/compare/" + to + "%0D" + from
+    }
+    return l.base + "/compare/" + from + "..." + to
+}
+
+// releaseLink returns the compare link of a release, or "" for the first release.
+func (l *repoLinks) releaseLink(r changelogRelease) string {
+    if l == nil || r.previous == "" {
+        return ""
+    }
+    return l.compare(r.previous, r.revision)
+}
+
+// commitLink returns the link to a commit, or "" without links.
+func (l *repoLinks) commitLink(hash string) string {
+    if l == nil {
+        return ""
+    }
+    return l.commit(hash)
+}
+
+// issueTextRegexp matches a "#12" issue reference in text that is not part of a word,
+// a cross-repository reference or a link.
+var issueTextRegexp = regexp.MustCompile(`(^|[^\w/\[&])#(\d+)\b`)
+
+// linkIssues turns the "#12" references in Markdown text into links.
+func (l *repoLinks) linkIssues(text string) string {
+    if l == nil {
+        return text
+    }
+    return issueTextRegexp.ReplaceAllStringFunc(text, func(m string) string {
+        sub := issueTextRegexp.FindStringSubmatch(m)
+        return fmt.Sprintf("%s[#%s](%s)", sub[1], sub[2], l.issue(sub[2]))
+    })
+}
@@ -0,0 +1,103 @@
+package internal
+
+import "testing"
+
+func TestParseRemoteURL(t *testing.T) {
+    tests := []struct {
+        remote string
+        want   string
+        ok     bool
+    }{
+        {"https://github.com/owner/repo.git", "https://github.com/owner/repo", true},
+        {"https://github.com/owner/repo/", "https://github.com/owner/repo", true},
+        {"http://git.example.com:8080/group/sub/repo", "http://git.example.com:8080/group/sub/repo", true},
+        {"git@github.com:owner/repo.git", "https://github.com/owner/repo", true},
+        {"github.com:owner/repo", "https://github.com/owner/repo", true},
+        {"ssh://git@gitlab.com:2222/group/repo.git", "https://gitlab.com/group/repo", true},
+        {"git://example.org/owner/repo", "https://example.org/owner/repo", true},
+        {"git+ssh://git@example.org/owner/repo.git", "https://example.org/owner/repo", true},
+        {"file:///srv/git/repo.git", "", false},
+        {"/srv/git/repo.git", "", false},
+        {"../repo", "", false},
+        {"https://github.com/repo", "", false},
+    }
+    for _, tt := range tests {
+        t.Run(tt.remote, func(t *testing.T) {
+            got, ok := parseRemoteURL(tt.remote)
+            if got != tt.want || ok != tt.ok {
+                t.Errorf("parseRemoteURL(%q) = %q, %v, want %q, %v", tt.remote, got, ok, tt.want, tt.ok)
+            }
+        })
+    }
+}
+
+func TestRepoLinks(t *testing.T) {
+    tests := []struct {
+        host    string
+        commit  string
+        issue   string
+        compare string
+    }{
+        {"github", "https://x.org/o/r/commit/abc", "https://x.org/o/r/issues/12", "https://x.org/o/r/compare/v1...v2"},
+        {"gitea", "https://x.org/o/r/commit/abc", "https://x.org/o/r/issues/12", "https://x.org/o/r/compare/v1...v2"},
+        {"gitlab", "https://x.org/o/r/-/commit/abc", "https://x.org/o/r/-/issues/12", "https://x.org/o/r/-/compare/v1...v2"},
+        {"bitbucket", "https://x.org/o/r/commits/abc", "https://x.org/o/r/issues/12", "https://x.org/o/r/branches/compare/v2%0Dv1"},
+    }
+    for _, tt := range tests {
+        t.Run(tt.host, func(t *testing.T) {
+            l := &repoLinks{base: "https://x.org/o/r", host: tt.host}
+            if got := l.commit("abc"); got != tt.commit {
+                t.Errorf("commit = %q, want %q", got, tt.commit)
+            }
+            if got := l.issue("12"); got != tt.issue {
+                t.Errorf("issue = %q, want %q", got, tt.issue)
+            }
+            if got := l.releaseLink(changelogRelease{version: "v2", revision: "v2", previous: "v1"}); got != tt.compare {
+                t.Errorf("releaseLink = %q, want %q", got, tt.compare)
+            }
+        })
+    }
+
+    var none *repoLinks
+    if none.releaseLink(changelogRelease{previous: "v1", revision: "v2"}) != "" || none.commitLink("abc") != "" || none.linkIssues("#1") != "#1" {
+        t.Error("nil links produced a link")
+    }
+    l := &repoLinks{base: "https://x.org/o/r", host: "github"}
+    if got := l.releaseLink(changelogRelease{version: "v1", revision: "v1"}); got != "" {
+        t.Errorf("first release link = %q, want none", got)
+    }
+}
+
+func TestGuessHost(t *testing.T) {
+    tests := map[string]string{
+        "https://github.com/o/r":         "github",
+        "https://gitlab.example.com/o/r": "gitlab",
+        "https://bitbucket.org/o/r":      "bitbucket",
+        "https://codeberg.org/o/r":       "gitea",
+        "https://git.example.com/o/r":    "github",
+    }
+    for base, want := range tests {
+        if got := guessHost(base); got != want {
+            t.Errorf("guessHost(%q) = %q, want %q", base, got, want)
+        }
+    }
+}
+
+func TestLinkIssues(t *testing.T) {
+    l := &repoLinks{base: "https://x.org/o/r", host: "github"}
+    tests := []struct {
+        text string
+        want string
+    }{
+        {"fix crash (#12)", "fix crash ([#12](https://x.org/o/r/issues/12))"},
+        {"#3 and #4", "[#3](https://x.org/o/r/issues/3) and [#4](https://x.org/o/r/issues/4)"},
+        {"see other/repo#5", "see other/repo#5"},
+        {"already [#6](https://x.org/o/r/issues/6)", "already [#6](https://x.org/o/r/issues/6)"},
+        {"color&#35;7 and abc#8", "color&#35;7 and abc#8"},
+    }
+    for _, tt := range tests {
+        if got := l.linkIssues(tt.text); got != tt.want {
+            t.Errorf("linkIssues(%q) = %q, want %q", tt.text, got, tt.want)
+        }
+    }
+}
@@ -0,0 +1,357 @@
+package internal
+
+import (
+    "os"
+    "os/exec"
+    "reflect"
+    "strings"
+    "testing"
+)
+
+// sectionSummary describes sections as "Title: subject, subject" for comparison.
+func sectionSummary(sections []changelogSection) []string {
+    var summary []string
+    for _, sc := range sections {
+        var subjects []string
+        for _, e := range sc.entries {
+            subjects = append(subjects, e.subject)
+        }
+        summary = append(summary, sc.title+": "+strings.Join(subjects, ", "))
+    }
+    return summary
+}
+
+func TestGroupSections(t *testing.T) {
+    entries := []commitEntry{
+        {ctype: "fix", subject: "fix one"},
+        {ctype: "feat", subject: "feat one"},
+        {ctype: "build", subject: "build one"},
+        {subject: "Update readme"},
+        {ctype: "feat", subject: "feat two"},
+        {ctype: "perf", subject: "perf one"},
+    }
+    sections := []ChangelogSection{
+        {Type: "feat", Title: "Features"},
+        {Type: "fix", Title: "Bug Fixes"},
+        {Type: "perf", Title: "Bug Fixes"},
+        {Type: "docs", Title: "Documentation"},
+    }
+
+    tests := []struct {
+        name string
+        cfg  ChangelogConfig
+        want []string
+    }{
+        {
+            name: "configured order with other last",
+            cfg:  ChangelogConfig{Sections: sections, Other: "Other"},
+            want: []string{
+                "Features: feat one, feat two",
+                "Bug Fixes: fix one, perf one",
+                "Other: build one, Update readme",
+            },
+        },
+        {
+            name: "unlisted types dropped without other",
+            cfg:  ChangelogConfig{Sections: sections},
+            want: []string{
+                "Features: feat one, feat two",
+                "Bug Fixes: fix one, perf one",
+            },
+        },
+        {
+            name: "section titled like other takes unlisted types",
+            cfg:  ChangelogConfig{Sections: append([]ChangelogSection{{Type: "chore", Title: "Misc"}}, sections...), Other: "Misc"},
+            want: []string{
+                "Misc: build one, Update readme",
+                "Features: feat one, feat two",
+                "Bug Fixes: fix one, perf one",
+            },
+        },
+        {
+            name: "order follows the config, not the history",
+            cfg:  ChangelogConfig{Sections: []ChangelogSection{{Type: "perf", Title: "Performance"}, {Type: "fix", Title: "Bug Fixes"}}},
+            want: []string{
+                "Performance: perf one",
+                "Bug Fixes: fix one",
+            },
+        },
+    }
+
+    for _, tt := range tests {
+        t.Run(tt.name, func(t *testing.T) {
+            if got := sectionSummary(groupSections(tt.cfg, entries)); !reflect.DeepEqual(got, tt.want) {
+                t.Errorf("groupSections() = %q, want %q", got, tt.want)
+            }
+        })
+    }
+}
+
+// initTestRepo creates an empty repository in a temporary directory and changes into it for the test.
+// It returns a function running git commands in it.
+func initTestRepo(t *testing.T) func(args ...string) {
+    t.Helper()
+    dir := t.TempDir()
+    wd, err := os.Getwd()
+    if err != nil {
+        t.Fatal(err)
+    }
+    if err := os.Chdir(dir); err != nil {
+        t.Fatal(err)
+    }
+    t.Cleanup(func() { os.Chdir(wd) })
+
+    git := func(args ...string) {
+        t.Helper()
+        args = append([]string{"-c", "user.name=Dev", "-c", "user.email=dev@example.com",
+            "-c", "commit.gpgsign=false", "-c", "tag.gpgsign=false"}, args...)
+        if out, err := exec.Command("git", args...).CombinedOutput(); err != nil {
+            t.Fatalf("git %v: %v\n%s", args, err, out)
+        }
+    }
+    git("init", "-q")
+    return git
+}
+
+func TestReadReleases(t *testing.T) {
+    git := initTestRepo(t)
+    commit := func(message string) { git("commit", "-q", "--allow-empty", "-m", message) }
+    commit("feat: first")
+    git("tag", "v1.0.0-rc1")
+    commit("fix: second")
+    git("tag", "v1.0.0-rc2")
+    git("tag", "-a", "-m", "Release 1.0.0", "v1.0.0")
+    commit("feat: third")
+    git("tag", "v1.1.0-rc1")
+    commit("fix: fourth")
+    git("tag", "v1.1.0")
+    git("tag", "not-a-release")
+    commit("feat: fifth")
+
+    cfg := LoadDefaultConfig().Changelog
+    filter, err := newChangelogFilter(ChangelogFilterConfig{})
+    if err != nil {
+        t.Fatal(err)
+    }
+
+    tests := []struct {
+        name string
+        rng  changelogRange
+        want []string
+    }{
+        {
+            name: "whole history",
+            want: []string{
+                " (since v1.1.0): fifth",
+                "v1.1.0 (since v1.1.0-rc1): fourth",
+                "v1.1.0-rc1 (since v1.0.0): third",
+                "v1.0.0 (since v1.0.0-rc1): second",
+                "v1.0.0-rc1 (since ): first",
+            },
+        },
+        {
+            name: "from a release",
+            rng:  changelogRange{from: "v1.0.0"},
+            want: []string{
+                " (since v1.1.0): fifth",
+                "v1.1.0 (since v1.1.0-rc1): fourth",
+                "v1.1.0-rc1 (since v1.0.0): third",
+            },
+        },
+        {
+            name: "up to a release",
+            rng:  changelogRange{from: "v1.0.0-rc1", to: "v1.1.0-rc1"},
+            want: []string{
+                "v1.1.0-rc1 (since v1.0.0): third",
+                "v1.0.0 (since v1.0.0-rc1): second",
+            },
+        },
+    }
+
+    for _, tt := range tests {
+        t.Run(tt.name, func(t *testing.T) {
+            releases, err := readReleases(cfg, tt.rng, filter)
+            if err != nil {
+                t.Fatalf("readReleases() error = %v", err)
+            }
+            var got []string
+            for _, r := range releases {
+                var subjects []string
+                for _, sc := range r.sections {
+                    for _, e := range sc.entries {
+                        subjects = append(subjects, e.subject)
+                    }
+                }
+                got = append(got, r.version+" (since "+r.previous+"): "+strings.Join(subjects, ", "))
+            }
+            if !reflect.DeepEqual(got, tt.want) {
+                t.Errorf("readReleases() = %q, want %q", got, tt.want)
+            }
+        })
+    }
+}
+
+func TestParseCommitMessageBreaking(t *testing.T) {
+    tests := []struct {
+        name      string
+        message   string
+        wantType  string
+        wantScope string
+        wantSubj  string
+        want      []string
+    }{
+        {
+            name:     "plain conventional header",
+            message:  "feat(api): add pagination",
+            wantType: "feat", wantScope: "api", wantSubj: "add pagination",
+        },
+        {
+            name:     "bang without footer",
+            message:  "feat(api)!: drop the v1 endpoints",
+            wantType: "feat", wantScope: "api", wantSubj: "drop the v1 endpoints",
+            want:     []string{"drop the v1 endpoints"},
+        },
+        {
+            name:     "footer with a continuation",
+            message:  "refactor!: rename config keys\n\nBREAKING CHANGE: keys are now camelCase\n  and old keys are ignored\nRefs #12",
+            wantType: "refactor", wantSubj: "rename config keys",
+            want:     []string{"keys are now camelCase\nand old keys are ignored"},
+        },
+        {
+            name:     "several footers",
+            message:  "fix: tighten parsing\n\nBody text.\n\nBREAKING CHANGE: empty values are rejected\nBREAKING-CHANGE: tabs are no longer trimmed",
+            wantType: "fix", wantSubj: "tighten parsing",
+            want:     []string{"empty values are rejected", "tabs are no longer trimmed"},
+        },
+        {
+            name:     "non-conventional header",
+            message:  "Update readme\n\nBREAKING CHANGE: not parsed",
+            wantSubj: "Update readme",
+        },
+    }
+
+    for _, tt := range tests {
+        t.Run(tt.name, func(t *testing.T) {
+            var e commitEntry
+            parseCommitMessage(&e, tt.message)
+            if e.ctype != tt.wantType || e.scope != tt.wantScope || e.subject != tt.wantSubj {
+                t.Errorf("header = %q, %q, %q, want %q, %q, %q", e.ctype, e.scope, e.subject, tt.wantType, tt.wantScope, tt.wantSubj)
+            }
+            if !reflect.DeepEqual(e.breaking, tt.want) {
+                t.Errorf("breaking = %q, want %q", e.breaking, tt.want)
+            }
+        })
+    }
+}
+
+func TestGroupSectionsBreaking(t *testing.T) {
+    entries := []commitEntry{
+        {ctype: "feat", subject: "add pagination"},
+        {ctype: "refactor", subject: "rename keys", description: "Details.", breaking: []string{"keys are camelCase", "old keys are ignored"}},
+    }
+    cfg := ChangelogConfig{
+        Sections:      []ChangelogSection{{Type: "feat", Title: "Features"}, {Type: "refactor", Title: "Refactoring"}},
+        BreakingTitle: "Breaking Changes",
+    }
+
+    sections := groupSections(cfg, entries)
+    want := []string{
+        "Breaking Changes: keys are camelCase, old keys are ignored",
+        "Features: add pagination",
+        "Refactoring: rename keys",
+    }
+    if got := sectionSummary(sections); !reflect.DeepEqual(got, want) {
+        t.Errorf("groupSections() = %q, want %q", got, want)
+    }
+    if sections[0].entries[0].description != "" || sections[2].entries[0].description != "Details." {
+        t.Error("breaking entries must drop the description and keep it in their type section")
+    }
+
+    cfg.BreakingTitle = ""
+    if got := sectionSummary(groupSections(cfg, entries)); !reflect.DeepEqual(got, want[1:]) {
+        t.Errorf("groupSections() without a breaking title = %q, want %q", got, want[1:])
+    }
+}
+
+func TestScopedSubject(t *testing.T) {
+    if got := scopedSubject(commitEntry{scope: "api", subject: "add pagination"}); got != "**api:** add pagination" {
+        t.Errorf("scopedSubject() = %q", got)
+    }
+    if got := scopedSubject(commitEntry{subject: "add pagination"}); got != "add pagination" {
+        t.Errorf("scopedSubject() without a scope = %q", got)
+    }
+}
+
+func TestParseCommitMessageBody(t *testing.T) {
+    tests := []struct {
+        name            string
+        message         string
+        wantDescription string
+        wantRefs        []string
+        wantReverts     string
+    }{
+        {
+            name:    "header only",
+            message: "fix: handle nil config\n",
+        },
+        {
+            name:            "first paragraph is the description",
+            message:         "feat: add login\n\nUsers can sign in\nwith a password.  \n\nSecond paragraph.",
+            wantDescription: "Users can sign in\nwith a password.",
+        },
+        {
+            name:     "footer is not a description",
+            message:  "fix: handle nil config\n\nCloses #12, #14\nRefs: owner/repo#3 PROJ-7\nSigned-off-by: Dev <dev@example.com>",
+            wantRefs: []string{"#12", "#14", "owner/repo#3", "PROJ-7"},
+        },
+        {
+            name:            "description and references",
+            message:         "feat: add login\n\nUsers can sign in.\n\nFixes #5",
+            wantDescription: "Users can sign in.",
+            wantRefs:        []string{"#5"},
+        },
+        {
+            name:            "revert",
+            message:         "Revert \"feat: add login\"\n\nThis reverts commit 0123456789abcdef0123456789abcdef01234567.",
+            wantDescription: "This reverts commit 0123456789abcdef0123456789abcdef01234567.",
+            wantReverts:     "0123456789abcdef0123456789abcdef01234567",
+        },
+    }
+
+    for _, tt := range tests {
+        t.Run(tt.name, func(t *testing.T) {
+            var e commitEntry
+            parseCommitMessage(&e, tt.message)
+            if e.description != tt.wantDescription {
+                t.Errorf("description = %q, want %q", e.description, tt.wantDescription)
+            }
+            if !reflect.DeepEqual(e.refs, tt.wantRefs) {
+                t.Errorf("refs = %q, want %q", e.refs, tt.wantRefs)
+            }
+            if e.reverts != tt.wantReverts {
+                t.Errorf("reverts = %q, want %q", e.reverts, tt.wantReverts)
+            }
+        })
+    }
+}
+
+func TestRefsSuffix(t *testing.T) {
+    tests := []struct {
+        subject string
+        refs    []string
+        want    string
+    }{
+        {"add login", nil, ""},
+        {"add login", []string{"#12", "#14"}, " (#12, #14)"},
+        {"add login (#12)", []string{"#12", "#14"}, " (#14)"},
+        {"add login (#12)", []string{"#12"}, ""},
+    }
+
+    for _, tt := range tests {
+        t.Run(tt.subject, func(t *testing.T) {
+            if got := refsSuffix(commitEntry{subject: tt.subject, refs: tt.refs}); got != tt.want {
+                t.Errorf("refsSuffix() = %q, want %q", got, tt.want)
+            }
+        })
+    }
+}
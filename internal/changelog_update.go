@@ -0,0 +1,120 @@
+package internal
+
+import (
+    "bytes"
+    "fmt"
+    "regexp"
+    "strings"
+)
+
+// markdownLinkRegexp matches a Markdown inline link and captures its text.
+var markdownLinkRegexp = regexp.MustCompile(`\[([^\]]*)\]\([^)]*\)`)
+
+// plainHeading removes the links and brackets from a heading, so that headings match
+// whether or not they were written with links.
+func plainHeading(heading string) string {
+    heading = markdownLinkRegexp.ReplaceAllString(strings.TrimSpace(heading), "$1")
+    return strings.NewReplacer("[", "", "]", "").Replace(heading)
+}
+
+// releaseHeadingRegexp turns the configured release heading into a regex that matches
+// a plain heading line and captures the version.
+func releaseHeadingRegexp(heading string) *regexp.Regexp {
+    pattern := regexp.QuoteMeta(plainHeading(heading))
+    pattern = strings.Replace(pattern, regexp.QuoteMeta("{version}"), `(?P<version>\S+?)`, 1)
+    pattern = strings.ReplaceAll(pattern, regexp.QuoteMeta("{version}"), `\S+?`)
+    pattern = strings.ReplaceAll(pattern, regexp.QuoteMeta("{date}"), `.*?`)
+    return regexp.MustCompile(`^` + pattern + `$`)
+}
+
+// updateChangelog inserts the releases newer than the newest one documented in an existing changelog.
+// The unreleased section is regenerated; the newest documented release and everything below it,
+// including hand-written notes, are kept as they are. In a file without releases, they are
+// inserted after the title block.
+func updateChangelog(style markdownStyle, marker string, existing []byte, releases []changelogRelease) ([]byte, error) {
+    lines := strings.SplitAfter(string(existing), "\n")
+    headingRe := releaseHeadingRegexp(style.releaseHeading)
+    unreleased := plainHeading(style.unreleasedHeading)
+
+    // Headings at the level of the release heading, as in "## ", end the title block.
+    level := strings.TrimLeft(style.releaseHeading, "#")
+    level = style.releaseHeading[:len(style.releaseHeading)-len(level)] + " "
+
+    // Find the newest documented release and the unreleased section above it. Without a
+    // documented release, the releases go after the title block, before any other section.
+    latest, latestLine, unreleasedLine, sectionLine := "", len(lines), -1, -1
+    for i, line := range lines {
+        line = plainHeading(line)
+        if unreleased != "" && line == unreleased {
+            if unreleasedLine < 0 {
+                unreleasedLine, sectionLine = i, -1
+            }
+            continue
+        }
+        if m := headingRe.FindStringSubmatch(line); m != nil {
+            latest, latestLine = m[1], i
+            break
+        }
+        if sectionLine < 0 && level != " " && strings.HasPrefix(line, level) {
+            sectionLine = i
+        }
+    }
+    if latest == "" && sectionLine >= 0 {
+        latestLine = sectionLine
+    }
+
+    // Keep only the releases that are not documented yet.
+    newReleases := releases
+    if latest != "" {
+        newReleases = nil
+        found := false
+        for _, r := range releases {
+            if r.version == latest {
+                found = true
+                break
+            }
+            newReleases = append(newReleases, r)
+        }
+        if !found {
+            return nil, fmt.Errorf("%s, the newest release in CHANGELOG, is not a release tag reachable from HEAD", latest)
+        }
+    }
+
+    // Drop the old unreleased section; it is regenerated with the new releases.
+    if unreleasedLine >= 0 {
+        lines = append(lines[:unreleasedLine:unreleasedLine], lines[latestLine:]...)
+        latestLine = unreleasedLine
+    }
+
+    insertAt := latestLine
+    if marker != "" {
+        insertAt = -1
+        for i, line := range lines {
+            if strings.TrimSpace(line) == strings.TrimSpace(marker) {
+                // Insert after the blank lines that follow the marker.
+                for insertAt = i + 1; insertAt < len(lines) && strings.TrimSpace(lines[insertAt]) == ""; insertAt++ {
+                }
+                break
+            }
+        }
+        if insertAt < 0 {
+            return nil, fmt.Errorf("changelog marker %q not found in CHANGELOG", marker)
+        }
+    }
+
+    var buf bytes.Buffer
+    before := strings.Join(lines[:insertAt], "")
+    buf.WriteString(before)
+    if len(newReleases) > 0 {
+        // Keep a blank line between the previous content and the new releases.
+        if before != "" && !strings.HasSuffix(before, "\n\n") {
+            if !strings.HasSuffix(before, "\n") {
+                buf.WriteString("\n")
+            }
+            buf.WriteString("\n")
+        }
+        style.renderReleases(&buf, newReleases)
+    }
+    buf.WriteString(strings.Join(lines[insertAt:], ""))
+    return buf.Bytes(), nil
+}
@@ -0,0 +1,108 @@
+package internal
+
+import (
+    "strings"
+    "testing"
+)
+
+func TestUpdateChangelog(t *testing.T) {
+    cfg := ChangelogConfig{ReleaseHeading: "## {version} ({date})", UnreleasedHeading: "## Unreleased"}
+    style, _ := markdownStyleFor(cfg, "markdown", nil)
+    release := func(version, subject string) changelogRelease {
+        entry := commitEntry{hash: "abc1234", date: "2025-03-13", author: "dev", subject: subject}
+        return changelogRelease{version: version, date: "2025-03-13", sections: []changelogSection{{title: "Features", entries: []commitEntry{entry}}}}
+    }
+    releases := []changelogRelease{release("", "wip"), release("v1.1.0", "new"), release("v1.0.0", "old")}
+
+    tests := []struct {
+        name     string
+        marker   string
+        existing string
+        want     string
+        wantErr  string
+    }{
+        {
+            name:     "inserts above the newest release and regenerates unreleased",
+            existing: "# Changelog\n\n## Unreleased\n\n### Features\n\n- stale\n\n## v1.0.0 (2025-03-13)\n\nHand-written notes.\n",
+            want: "# Changelog\n\n## Unreleased\n\n### Features\n\n- [abc1234] 2025-03-13 by dev: wip\n\n" +
+                "## v1.1.0 (2025-03-13)\n\n### Features\n\n- [abc1234] 2025-03-13 by dev: new\n\n" +
+                "## v1.0.0 (2025-03-13)\n\nHand-written notes.\n",
+        },
+        {
+            name:     "matches linked headings",
+            existing: "# Changelog\n\n## [v1.0.0](https://example.com/compare/v0.9.0...v1.0.0) (2025-03-13)\n",
+            want: "# Changelog\n\n## Unreleased\n\n### Features\n\n- [abc1234] 2025-03-13 by dev: wip\n\n" +
+                "## v1.1.0 (2025-03-13)\n\n### Features\n\n- [abc1234] 2025-03-13 by dev: new\n\n" +
+                "## [v1.0.0](https://example.com/compare/v0.9.0...v1.0.0) (2025-03-13)\n",
+        },
+        {
+            name:     "inserts after the title block without releases",
+            existing: "# Changelog\n\nIntro.\n\n## Notes\n\nKeep me.\n",
+            want: "# Changelog\n\nIntro.\n\n## Unreleased\n\n### Features\n\n- [abc1234] 2025-03-13 by dev: wip\n\n" +
+                "## v1.1.0 (2025-03-13)\n\n### Features\n\n- [abc1234] 2025-03-13 by dev: new\n\n" +
+                "## v1.0.0 (2025-03-13)\n\n### Features\n\n- [abc1234] 2025-03-13 by dev: old\n\n" +
+                "## Notes\n\nKeep me.\n",
+        },
+        {
+            name:     "inserts below the marker",
+            marker:   "<!-- releases -->",
+            existing: "# Changelog\n\n<!-- releases -->\n\n## v1.1.0 (2025-03-13)\n",
+            want: "# Changelog\n\n<!-- releases -->\n\n## Unreleased\n\n### Features\n\n- [abc1234] 2025-03-13 by dev: wip\n\n" +
+                "## v1.1.0 (2025-03-13)\n",
+        },
+        {
+            name:     "missing marker",
+            marker:   "<!-- releases -->",
+            existing: "# Changelog\n\n## v1.1.0 (2025-03-13)\n",
+            wantErr:  "marker",
+        },
+        {
+            name:     "unknown documented release",
+            existing: "# Changelog\n\n## v2.0.0 (2025-03-13)\n",
+            wantErr:  "v2.0.0",
+        },
+    }
+    for _, tt := range tests {
+        t.Run(tt.name, func(t *testing.T) {
+            got, err := updateChangelog(style, tt.marker, []byte(tt.existing), releases)
+            if tt.wantErr != "" {
+                if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+                    t.Fatalf("error = %v, want one mentioning %q", err, tt.wantErr)
+                }
+                return
+            }
+            if err != nil {
+                t.Fatalf("unexpected error: %v", err)
+            }
+            if string(got) != tt.want {
+                t.Errorf("got:\n%s\nwant:\n%s", got, tt.want)
+            }
+        })
+    }
+}
+
+func TestReleaseHeadingRegexp(t *testing.T) {
+    tests := []struct {
+        heading string
+        line    string
+        version string // Empty when the line must not match
+    }{
+        {"## {version} ({date})", "## v1.2.0 (2025-03-13)", "v1.2.0"},
+        {"## {version} ({date})", "## [v1.2.0](https://example.com/compare/v1.1.0...v1.2.0) (2025-03-13)", "v1.2.0"},
+        {"## [{version}] - {date}", "## [1.2.0] - 2025-03-13", "1.2.0"},
+        {"## {version} ({date})", "## Unreleased", ""},
+        {"## {version} ({date})", "### v1.2.0 (2025-03-13)", ""},
+    }
+    for _, tt := range tests {
+        t.Run(tt.line, func(t *testing.T) {
+            m := releaseHeadingRegexp(tt.heading).FindStringSubmatch(plainHeading(tt.line))
+            version := ""
+            if m != nil {
+                version = m[1]
+            }
+            if version != tt.version {
+                t.Errorf("version = %q, want %q", version, tt.version)
+            }
+        })
+    }
+}
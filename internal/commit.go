@@ -1,6 +1,7 @@
 package internal
 
 import (
+    "context"
     "flag"
     "fmt"
     "log"
@@ -22,6 +23,7 @@ func isGitRepo() bool {
 func CommitCommand(args []string) {
     commitFlags := flag.NewFlagSet("commit", flag.ExitOnError)
     allFlag := commitFlags.Bool("all", false, "Automatically stage modified/deleted files")
+    fullFileFlag := commitFlags.Bool("full-file", false, "Scan whole staged files for secrets, not only the added lines")
     commitFlags.Parse(args)
 
     if !isGitRepo() {
@@ -30,11 +32,7 @@ func CommitCommand(args []string) {
     }
 
     // Load configuration from external file.
-    config, err := LoadConfig()
-    if err != nil {
-        log.Printf("No external config loaded: %v; using built-in default\n", err)
-        config = LoadDefaultConfig()
-    }
+    config := loadConfigOrDefault()
 
     // Collect user input based on the configuration.
     answers := CollectUserInput(config)
@@ -47,12 +45,12 @@ func CommitCommand(args []string) {
     }
 
     // Within CommitCommand after rendering the message:
-    if err := LintCommitMessage(message); err != nil {
+    if err := LintCommitMessage(config, message); err != nil {
         log.Printf("Commit message linting failed: %v\n", err)
         return
     }
 
-    if err := LintSensitiveFiles(); err != nil {
+    if err := LintSensitiveFiles(context.Background(), config.Secrets, SecretScanOptions{FullFile: *fullFileFlag, WorkingTree: *allFlag}); err != nil {
         log.Printf("%v\n", err)
         return
     }
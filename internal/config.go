@@ -8,6 +8,7 @@ import (
     "log"
     "os"
     "path/filepath"
+    "reflect"
     "strings"
     "text/template"
 
@@ -42,9 +43,113 @@ type MessageConfig struct {
     Template string `json:"template"`
 }
 
+// IgnoreConfig describes which commits are exempt from commit message linting.
+type IgnoreConfig struct {
+    Builtin    bool     `json:"builtin"`              // Skip merge, revert, fixup/squash and bot commits
+    Headers    []string `json:"headers,omitempty"`    // Regexes matched against the commit header
+    Authors    []string `json:"authors,omitempty"`    // Regexes matched against "Name <email>"
+    HashesFile string   `json:"hashesFile,omitempty"` // File listing commit hashes to skip, relative to the repo root
+}
+
+// SubjectConfig holds the style checks for the subject part of the header.
+type SubjectConfig struct {
+    Imperative bool              `json:"imperative"`          // Flag subjects that do not start with an imperative verb
+    LowerCase  bool              `json:"lowerCase"`           // Require the subject to start with a lower-case letter
+    Verbs      map[string]string `json:"verbs,omitempty"`     // Extra non-imperative word -> imperative form
+    Names      []string          `json:"names,omitempty"`     // Proper nouns and product names that may start a subject capitalized
+    WordsFile  string            `json:"wordsFile,omitempty"` // Repository word list ("word imperative" per line), relative to the repo root
+}
+
+// LintConfig holds the commit message linting settings.
+type LintConfig struct {
+    HeaderMaxLength   int               `json:"headerMaxLength"`
+    BodyMaxLineLength int               `json:"bodyMaxLineLength"`
+    TypeAliases       map[string]string `json:"typeAliases,omitempty"` // Misspelled type -> accepted type, used by --fix
+    Subject           SubjectConfig     `json:"subject"`
+    Ignore            IgnoreConfig      `json:"ignore"`
+}
+
+// EntropyThreshold is the minimum entropy and length for a candidate secret of one charset.
+type EntropyThreshold struct {
+    MinEntropy float64 `json:"minEntropy"`
+    MinLength  int     `json:"minLength"`
+}
+
+// EntropyConfig holds the entropy thresholds applied to values found next to secret keywords.
+type EntropyConfig struct {
+    Hex     EntropyThreshold `json:"hex"`
+    Base64  EntropyThreshold `json:"base64"`
+    Generic EntropyThreshold `json:"generic"`
+}
+
+// SecretsAllowlist lists the findings that are acknowledged and never reported.
+type SecretsAllowlist struct {
+    Paths   []string `json:"paths,omitempty"`   // Regexes matched against the file path
+    Regexes []string `json:"regexes,omitempty"` // Regexes matched against the detected secret
+}
+
+// CommandDetectorConfig declares an external command that detects secrets. The command reads
+// the file content on stdin and prints a JSON array of findings.
+type CommandDetectorConfig struct {
+    Name    string   `json:"name"`
+    Command []string `json:"command"`           // Program and arguments
+    Timeout int      `json:"timeout,omitempty"` // Seconds before the command is killed (default 30)
+}
+
+// SecretsConfig holds the secret scanner settings.
+type SecretsConfig struct {
+    Include      []string                `json:"include,omitempty"` // Gitignore-style patterns; when set, only matching files are scanned
+    Exclude      []string                `json:"exclude,omitempty"` // Gitignore-style patterns of files that are never scanned
+    MaxFileSize  int64                   `json:"maxFileSize"`       // Skip files larger than this many bytes; 0 disables the limit
+    ScanMessages bool                    `json:"scanMessages"`      // Also scan commit messages, in the commit flow and in lint
+    Entropy      EntropyConfig           `json:"entropy"`
+    Allowlist    SecretsAllowlist        `json:"allowlist"`
+    Baseline     string                  `json:"baseline,omitempty"`  // Baseline file of known findings, relative to the repo root
+    Detectors    []CommandDetectorConfig `json:"detectors,omitempty"` // External detectors run after the built-in rules
+}
+
+// ChangelogSection maps a commit type to a changelog section title.
+type ChangelogSection struct {
+    Type  string `json:"type"`
+    Title string `json:"title"`
+}
+
+// ChangelogFilterConfig describes which commits are left out of the changelog.
+type ChangelogFilterConfig struct {
+    IncludeTypes   []string `json:"includeTypes"`   // Only keep these types when not empty
+    ExcludeTypes   []string `json:"excludeTypes"`   // Leave out these types
+    ExcludeMerges  bool     `json:"excludeMerges"`  // Leave out merge commits
+    ExcludeAuthors []string `json:"excludeAuthors"` // Regexes matched against "Name <email>"
+    ExcludeHeaders []string `json:"excludeHeaders"` // Regexes matched against the commit header
+    HideReverted   bool     `json:"hideReverted"`   // Leave out commits reverted in the same release, and their reverts
+}
+
+// ChangelogConfig holds the changelog generation settings.
+type ChangelogConfig struct {
+    Sections      []ChangelogSection `json:"sections"`      // Sections in output order; types may share a title
+    Other         string             `json:"other"`         // Title of the section for unlisted types; empty hides those commits
+    BreakingTitle string             `json:"breakingTitle"` // Title of the breaking changes section, listed first; empty hides it
+    Description   bool               `json:"description"`   // Write the first body paragraph under each entry
+    TagPrefix     string             `json:"tagPrefix"`     // Prefix of release tags, e.g. "v"
+    TagPattern    string             `json:"tagPattern"`    // Regex the rest of a release tag must match
+
+    ReleaseHeading    string `json:"releaseHeading"`    // Heading of a release, with {version} and {date} placeholders
+    UnreleasedHeading string `json:"unreleasedHeading"` // Heading of the commits after the latest release
+    Marker            string `json:"marker"`            // Line after which incremental updates insert new releases; empty inserts above the newest one
+
+    Links          bool   `json:"links"`          // Link commits, issues and releases to the repository's web pages
+    RepositoryURL  string `json:"repositoryUrl"`  // Web URL of the repository; empty derives it from the origin remote
+    RepositoryHost string `json:"repositoryHost"` // github, gitlab, bitbucket or gitea; empty guesses from the URL
+
+    Filter ChangelogFilterConfig `json:"filter"`
+}
+
 // Config is the root configuration structure.
 type Config struct {
-    Message MessageConfig `json:"message"`
+    Message   MessageConfig   `json:"message"`
+    Lint      LintConfig      `json:"lint"`
+    Secrets   SecretsConfig   `json:"secrets"`
+    Changelog ChangelogConfig `json:"changelog"`
 }
 
 // =======================
@@ -52,8 +157,10 @@ type Config struct {
 // =======================
 
 // LoadConfig loads the configuration from the installed config file or fallback.
+// Settings missing from the file keep their built-in default values; lists and maps in the file
+// replace the default ones.
 func LoadConfig() (Config, error) {
-    var cfg Config
+    cfg := LoadDefaultConfig()
 
     exePath, err := os.Executable()
     if err != nil {
@@ -67,7 +174,7 @@ func LoadConfig() (Config, error) {
             if err != nil {
                 return cfg, fmt.Errorf("failed to read config file %s: %v", configPath, err)
             }
-            if err := json.Unmarshal(data, &cfg); err != nil {
+            if cfg, err = parseConfig(data); err != nil {
                 return cfg, fmt.Errorf("failed to parse config file %s: %v", configPath, err)
             }
             log.Printf("Loaded config from %s\n", configPath)
@@ -79,6 +186,44 @@ func LoadConfig() (Config, error) {
     return LoadDefaultConfig(), nil
 }
 
+// parseConfig decodes a config file over the built-in defaults.
+func parseConfig(data []byte) (Config, error) {
+    cfg := LoadDefaultConfig()
+    err := mergeConfig(reflect.ValueOf(&cfg).Elem(), data)
+    return cfg, err
+}
+
+// mergeConfig decodes JSON over dst. Objects that map to structs are merged field by field, so
+// settings missing from them keep their defaults. Every other value, including lists and maps,
+// replaces the default as a whole, so that a shorter list or map in the file is taken as it is.
+func mergeConfig(dst reflect.Value, data []byte) error {
+    if dst.Kind() != reflect.Struct {
+        dst.Set(reflect.Zero(dst.Type()))
+        return json.Unmarshal(data, dst.Addr().Interface())
+    }
+    var fields map[string]json.RawMessage
+    if err := json.Unmarshal(data, &fields); err != nil {
+        return err
+    }
+    t := dst.Type()
+    for i := 0; i < t.NumField(); i++ {
+        name := strings.Split(t.Field(i).Tag.Get("json"), ",")[0]
+        if name == "" {
+            name = t.Field(i).Name
+        }
+        // Keys match field names case-insensitively, as in encoding/json.
+        for key, raw := range fields {
+            if strings.EqualFold(key, name) {
+                if err := mergeConfig(dst.Field(i), raw); err != nil {
+                    return fmt.Errorf("%s: %v", key, err)
+                }
+                break
+            }
+        }
+    }
+    return nil
+}
+
 // LoadDefaultConfig returns a built-in default configuration as a fallback.
 func LoadDefaultConfig() Config {
     defaultJSON := `{
@@ -130,6 +275,101 @@ func LoadDefaultConfig() Config {
                 }
             ],
             "template": "{{.type}}{{if .scope}}({{.scope}}){{end}}: {{.subject}}{{if .body}}\n\n{{.body}}{{end}}{{if .footer}}\n\n{{.footer}}{{end}}"
+        },
+        "lint": {
+            "headerMaxLength": 100,
+            "bodyMaxLineLength": 100,
+            "typeAliases": {
+                "feature": "feat",
+                "features": "feat",
+                "bugfix": "fix",
+                "hotfix": "fix",
+                "doc": "docs",
+                "styles": "style",
+                "refactoring": "refactor",
+                "performance": "perf",
+                "tests": "test",
+                "wip": "WIP"
+            },
+            "subject": {
+                "imperative": true,
+                "lowerCase": true,
+                "verbs": {},
+                "names": [],
+                "wordsFile": ".gommitizen-words"
+            },
+            "ignore": {
+                "builtin": true,
+                "headers": [],
+                "authors": [],
+                "hashesFile": ".gommitizen-ignore-revs"
+            }
+        },
+        "secrets": {
+            "include": [],
+            "exclude": [
+                "vendor/",
+                "node_modules/",
+                "third_party/",
+                "go.sum",
+                "package-lock.json",
+                "npm-shrinkwrap.json",
+                "yarn.lock",
+                "pnpm-lock.yaml",
+                "Cargo.lock",
+                "Gemfile.lock",
+                "composer.lock",
+                "poetry.lock",
+                "Pipfile.lock",
+                "*.min.js",
+                "*.map"
+            ],
+            "maxFileSize": 1048576,
+            "scanMessages": true,
+            "entropy": {
+                "hex": { "minEntropy": 3.0, "minLength": 16 },
+                "base64": { "minEntropy": 3.8, "minLength": 16 },
+                "generic": { "minEntropy": 3.3, "minLength": 10 }
+            },
+            "allowlist": {
+                "paths": [],
+                "regexes": []
+            },
+            "baseline": ".gommitizen-secrets.json",
+            "detectors": []
+        },
+        "changelog": {
+            "sections": [
+                { "type": "feat", "title": "Features" },
+                { "type": "fix", "title": "Bug Fixes" },
+                { "type": "perf", "title": "Performance" },
+                { "type": "refactor", "title": "Code Refactoring" },
+                { "type": "revert", "title": "Reverts" },
+                { "type": "docs", "title": "Documentation" },
+                { "type": "style", "title": "Styles" },
+                { "type": "test", "title": "Tests" },
+                { "type": "chore", "title": "Chores" },
+                { "type": "WIP", "title": "Work in Progress" }
+            ],
+            "other": "Other",
+            "breakingTitle": "⚠ Breaking Changes",
+            "description": false,
+            "tagPrefix": "v",
+            "tagPattern": "^\\d+\\.\\d+\\.\\d+(?:[-+][0-9A-Za-z.-]+)?$",
+            "releaseHeading": "## {version} ({date})",
+            "unreleasedHeading": "## Unreleased",
+            "marker": "",
+            "links": true,
+            "repositoryUrl": "",
+            "repositoryHost": "",
+            "filter": {
+                "includeTypes": [],
+                "excludeTypes": ["chore", "style", "WIP"],
+                "excludeMerges": true,
+                "excludeAuthors": ["\\[bot\\]"],
+                "excludeHeaders": [],
+                "hideReverted": true
+            }
         }
     }`
     var cfg Config
@@ -139,6 +379,16 @@ func LoadDefaultConfig() Config {
     return cfg
 }
 
+// loadConfigOrDefault loads the external config, falling back to the built-in default on error.
+func loadConfigOrDefault() Config {
+    config, err := LoadConfig()
+    if err != nil {
+        log.Printf("No external config loaded: %v; using built-in default\n", err)
+        return LoadDefaultConfig()
+    }
+    return config
+}
+
 // =======================
 // Helpers
 // =======================
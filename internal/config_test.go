@@ -0,0 +1,97 @@
+package internal
+
+import (
+    "reflect"
+    "testing"
+)
+
+func TestParseConfig(t *testing.T) {
+    defaults := LoadDefaultConfig()
+
+    tests := []struct {
+        name  string
+        json  string
+        check func(t *testing.T, cfg Config)
+    }{
+        {
+            name: "shorter item list replaces the default items",
+            json: `{"message": {"items": [{"name": "ticket", "form": "input"}]}}`,
+            check: func(t *testing.T, cfg Config) {
+                want := []Item{{Name: "ticket", Form: "input"}}
+                if !reflect.DeepEqual(cfg.Message.Items, want) {
+                    t.Errorf("items = %+v, want %+v", cfg.Message.Items, want)
+                }
+                if cfg.Message.Template != defaults.Message.Template {
+                    t.Error("template lost its default")
+                }
+            },
+        },
+        {
+            name: "map replaces the default map",
+            json: `{"lint": {"typeAliases": {"feature": "feat"}}}`,
+            check: func(t *testing.T, cfg Config) {
+                want := map[string]string{"feature": "feat"}
+                if !reflect.DeepEqual(cfg.Lint.TypeAliases, want) {
+                    t.Errorf("typeAliases = %v, want %v", cfg.Lint.TypeAliases, want)
+                }
+                if cfg.Lint.HeaderMaxLength != defaults.Lint.HeaderMaxLength || !reflect.DeepEqual(cfg.Lint.Subject, defaults.Lint.Subject) {
+                    t.Error("other lint settings lost their defaults")
+                }
+            },
+        },
+        {
+            name: "nested struct fields are merged",
+            json: `{"secrets": {"entropy": {"hex": {"minEntropy": 2.5}}}, "changelog": {"filter": {"excludeTypes": []}}}`,
+            check: func(t *testing.T, cfg Config) {
+                if cfg.Secrets.Entropy.Hex.MinEntropy != 2.5 || cfg.Secrets.Entropy.Hex.MinLength != defaults.Secrets.Entropy.Hex.MinLength {
+                    t.Errorf("hex threshold = %+v", cfg.Secrets.Entropy.Hex)
+                }
+                if cfg.Secrets.Entropy.Base64 != defaults.Secrets.Entropy.Base64 {
+                    t.Error("base64 threshold lost its default")
+                }
+                if len(cfg.Changelog.Filter.ExcludeTypes) != 0 {
+                    t.Errorf("excludeTypes = %v, want empty", cfg.Changelog.Filter.ExcludeTypes)
+                }
+                if !reflect.DeepEqual(cfg.Changelog.Sections, defaults.Changelog.Sections) {
+                    t.Error("changelog sections lost their defaults")
+                }
+            },
+        },
+        {
+            name: "false and zero values override the defaults",
+            json: `{"secrets": {"scanMessages": false, "maxFileSize": 0}, "LINT": {"headerMaxLength": 50}}`,
+            check: func(t *testing.T, cfg Config) {
+                if cfg.Secrets.ScanMessages || cfg.Secrets.MaxFileSize != 0 {
+                    t.Errorf("secrets = %+v", cfg.Secrets)
+                }
+                if cfg.Lint.HeaderMaxLength != 50 {
+                    t.Errorf("headerMaxLength = %d, want 50", cfg.Lint.HeaderMaxLength)
+                }
+            },
+        },
+        {
+            name: "empty file keeps every default",
+            json: `{}`,
+            check: func(t *testing.T, cfg Config) {
+                if !reflect.DeepEqual(cfg, defaults) {
+                    t.Error("config differs from the defaults")
+                }
+            },
+        },
+    }
+    for _, tt := range tests {
+        t.Run(tt.name, func(t *testing.T) {
+            cfg, err := parseConfig([]byte(tt.json))
+            if err != nil {
+                t.Fatalf("parseConfig: %v", err)
+            }
+            tt.check(t, cfg)
+        })
+    }
+
+    for _, bad := range []string{`[]`, `{"lint": {"headerMaxLength": "long"}}`, `{`} {
+        if _, err := parseConfig([]byte(bad)); err == nil {
+            t.Errorf("parseConfig(%s) succeeded, want an error", bad)
+        }
+    }
+}
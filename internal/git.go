@@ -0,0 +1,31 @@
+package internal
+
+import (
+    "bytes"
+    "fmt"
+    "os/exec"
+    "strings"
+)
+
+// gitRoot returns the top-level directory of the current repository.
+func gitRoot() (string, error) {
+    out, err := exec.Command("git", "rev-parse", "--show-toplevel").Output()
+    if err != nil {
+        return "", fmt.Errorf("failed to get git root: %v", err)
+    }
+    return strings.TrimSpace(string(out)), nil
+}
+
+// splitNUL is a bufio.SplitFunc that splits NUL-separated records, as produced by "git log -z".
+func splitNUL(data []byte, atEOF bool) (advance int, token []byte, err error) {
+    if atEOF && len(data) == 0 {
+        return 0, nil, nil
+    }
+    if i := bytes.IndexByte(data, 0); i >= 0 {
+        return i + 1, data[:i], nil
+    }
+    if atEOF {
+        return len(data), data, nil
+    }
+    return 0, nil, nil
+}
@@ -2,214 +2,247 @@ package internal
 
 import (
     "bufio"
+    "context"
+    "bytes"
     "fmt"
     "strings"
-    "math"
     "os"
     "os/exec"
-    "regexp"
+    "runtime"
+    "sort"
     "sync"
-    "path/filepath"
 
     "gommitizen/internal/utils"
 )
 
-// calculateEntropy computes the Shannon entropy of a string.
-func calculateEntropy(s string) float64 {
-    freq := make(map[rune]float64)
-    for _, r := range s {
-        freq[r]++
+// SecretScanOptions holds the options for scanning staged changes for secrets.
+type SecretScanOptions struct {
+    FullFile    bool // Scan the whole staged version of each file instead of only the added lines
+    WorkingTree bool // Include tracked working-tree changes, as staged by "git commit -a"
+}
+
+// LintSensitiveFiles scans the changes about to be committed for sensitive information with a
+// bounded worker pool, skipping the files excluded by the secrets config, and reports every finding.
+// The index version is scanned, so unstaged edits are ignored and secrets that are staged but gone
+// from the working tree are caught.
+func LintSensitiveFiles(ctx context.Context, cfg SecretsConfig, opts SecretScanOptions) error {
+    scanner, err := newSecretScanner(cfg, true)
+    if err != nil {
+        return err
+    }
+    findings, err := scanner.scanAll(ctx, stagedSource(opts.FullFile, opts.WorkingTree))
+    if err != nil {
+        return err
+    }
+    if len(findings) == 0 {
+        return nil
     }
-    var entropy float64
-    l := float64(len(s))
-    for _, count := range freq {
-        p := count / l
-        entropy -= p * math.Log2(p)
+
+    lines := make([]string, len(findings))
+    for i, f := range findings {
+        lines[i] = f.String()
     }
-    return entropy
+    return fmt.Errorf("linting failed: sensitive information detected:\n%s", strings.Join(lines, "\n"))
 }
 
-// LintSensitiveFiles checks staged files concurrently for sensitive information,
-// ignoring files in the "gommitizen" directory.
-func LintSensitiveFiles() error {
-    gitRootBytes, err := exec.Command("git", "rev-parse", "--show-toplevel").Output()
+// LintCommitMessage checks if the commit message adheres to predefined rules
+// and, unless disabled, that it contains no secrets.
+func LintCommitMessage(cfg Config, message string) error {
+    linter, err := newCommitLinter(cfg)
     if err != nil {
-        return fmt.Errorf("failed to get git root: %v", err)
+        return err
     }
-    gitRoot := strings.TrimSpace(string(gitRootBytes))
+    return issuesError(linter.lint(message))
+}
 
-    cmd := exec.Command("git", "diff", "--cached", "--name-only")
-    output, err := cmd.Output()
+// LintCurrentCommitMessage lints the current commit messages.
+func LintCurrentCommitMessage() error {
+    cfg := loadConfigOrDefault()
+    ignorer, err := newLintIgnorer(cfg.Lint.Ignore)
     if err != nil {
-        return fmt.Errorf("failed to get staged files: %v", err)
+        return err
     }
 
-    files := strings.Split(strings.TrimSpace(string(output)), "\n")
-    if len(files) == 0 || files[0] == "" {
+    // Get the latest commit from HEAD.
+    cmd := exec.Command("git", "log", "-1", "-z", "--pretty=format:"+commitRecordFormat)
+    output, err := cmd.Output()
+    if err != nil {
+        return fmt.Errorf("failed to get commit message: %v", err)
+    }
+    rec, ok := parseCommitRecord(strings.TrimRight(string(output), "\x00"))
+    if !ok {
+        return fmt.Errorf("failed to get commit message: empty output")
+    }
+    if reason := ignorer.reason(rec); reason != "" {
+        printSkipped(rec.hash, reason)
         return nil
     }
+    // Lint the commit message using LintCommitMessage.
+    return LintCommitMessage(cfg, rec.message)
+}
 
-    // Regex patterns for sensitive info
-    patterns := []string{
-        `(?i)\bpassword\b`,
-        `(?i)\bsecret\b`,
-        `(?i)\bapikey\b`,
-        `(?i)\btoken\b`,
-        `(?i)\bcredential\b`,
-    }
+// LintAllOptions holds the options for linting the whole history.
+type LintAllOptions struct {
+    MaxCount int  // Lint at most this many commits (0 means no limit)
+    Progress bool // Print progress to stderr while linting
+}
 
-    var regexes []*regexp.Regexp
-    for _, pat := range patterns {
-        re, err := regexp.Compile(pat)
-        if err != nil {
-            return fmt.Errorf("failed to compile regex %s: %v", pat, err)
-        }
-        regexes = append(regexes, re)
-    }
+// commitRecordFormat is the "git log" format parsed by parseCommitRecord.
+const commitRecordFormat = "%H%n%P%n%an <%ae>%n%B"
 
-    var wg sync.WaitGroup
-    errCh := make(chan error, len(files))
+// commitRecord is a single commit read from the history stream.
+type commitRecord struct {
+    hash    string
+    parents []string
+    author  string
+    message string
+}
 
-    for _, file := range files {
-        if shouldSkipFile(file) {
-            continue
-        }
+// parseCommitRecord parses a "<hash>\n<parents>\n<author>\n<message>" record from "git log -z".
+func parseCommitRecord(raw string) (commitRecord, bool) {
+    raw = strings.TrimLeft(raw, "\n")
+    if raw == "" {
+        return commitRecord{}, false
+    }
+    parts := strings.SplitN(raw, "\n", 4)
+    if len(parts) < 3 {
+        return commitRecord{}, false
+    }
+    rec := commitRecord{
+        hash:    parts[0],
+        parents: strings.Fields(parts[1]),
+        author:  parts[2],
+    }
+    if len(parts) == 4 {
+        rec.message = strings.TrimSpace(parts[3])
+    }
+    return rec, true
+}
 
-        absPath := filepath.Join(gitRoot, file)
+// printSkipped reports a commit that was exempted from linting.
+func printSkipped(hash, reason string) {
+    if len(hash) > 12 {
+        hash = hash[:12]
+    }
+    fmt.Printf("%s %s: %s\n", utils.Color("skipped", "yellow"), hash, reason)
+}
 
-        info, err := os.Stat(absPath)
-        if err != nil {
-            fmt.Printf("Warning: unable to stat file %s: %v\n", utils.Color(file, "yellow"), err)
-            continue
-        }
+// LintAllCommitMessage lints all commit messages reachable from HEAD.
+// The history is streamed from a single "git log -z" and linted by a bounded worker pool;
+// errors are reported in history order regardless of which worker found them.
+func LintAllCommitMessage(opts LintAllOptions) error {
+    cfg := loadConfigOrDefault()
+    ignorer, err := newLintIgnorer(cfg.Lint.Ignore)
+    if err != nil {
+        return err
+    }
+    linter, err := newCommitLinter(cfg)
+    if err != nil {
+        return err
+    }
 
-        // Skip directories and special files
-        if !info.Mode().IsRegular() {
-            continue
-        }
+    args := []string{"log", "-z", "--pretty=format:" + commitRecordFormat}
+    if opts.MaxCount > 0 {
+        args = append(args, fmt.Sprintf("--max-count=%d", opts.MaxCount))
+    }
+    cmd := exec.Command("git", args...)
+    var stderr bytes.Buffer
+    cmd.Stderr = &stderr
+    stdout, err := cmd.StdoutPipe()
+    if err != nil {
+        return fmt.Errorf("failed to read commit history: %v", err)
+    }
+    if err := cmd.Start(); err != nil {
+        return fmt.Errorf("failed to run git log: %v", err)
+    }
 
-        // Try open the file, skip if unreadable
-        f, err := os.Open(absPath)
-        if err != nil {
-            fmt.Printf("Warning: unable to open file %s: %v\n", utils.Color(file, "yellow"), err)
-            continue
-        }
+    type job struct {
+        index int
+        rec   commitRecord
+    }
+    type result struct {
+        index  int
+        hash   string
+        reason string // Why the commit was skipped, empty for lint failures
+        text   string
+    }
+
+    workers := runtime.GOMAXPROCS(0)
+    jobs := make(chan job, workers*4)
+    results := make(chan result, workers*4)
 
+    var wg sync.WaitGroup
+    for i := 0; i < workers; i++ {
         wg.Add(1)
-        go func(file string, f *os.File) {
+        go func() {
             defer wg.Done()
-            defer f.Close()
-
-            scanner := bufio.NewScanner(f)
-            buf := make([]byte, 0, 64*1024) // 64KB initial buffer
-            scanner.Buffer(buf, 1024*1024) // Max 1MB line size
-
-            for scanner.Scan() {
-                line := scanner.Bytes()
-                for _, re := range regexes {
-                    if re.Match(line) {
-                        match := re.Find(line)
-                        entropy := calculateEntropy(string(match))
-                        if entropy > 3.5 {
-                            errCh <- fmt.Errorf(
-                                "sensitive information detected in file: %s (pattern: %s, entropy: %.2f)",
-                                utils.Color(file, "yellow"), re.String(), entropy,
-                            )
-                            return
-                        }
+            for j := range jobs {
+                if reason := ignorer.reason(j.rec); reason != "" {
+                    results <- result{index: j.index, hash: j.rec.hash, reason: reason}
+                    continue
+                }
+                if err := issuesError(linter.lint(j.rec.message)); err != nil {
+                    results <- result{
+                        index: j.index,
+                        hash:  j.rec.hash,
+                        text:  fmt.Sprintf("commit %s: %v", utils.Color(j.rec.hash, "red"), err),
                     }
                 }
             }
-
-            if err := scanner.Err(); err != nil {
-                fmt.Printf("Warning: error reading file %s: %v\n", utils.Color(file, "yellow"), err)
-            }
-
-        }(file, f)
+        }()
     }
 
-    wg.Wait()
-    close(errCh)
-
-    var combinedErrs []string
-    for err := range errCh {
-        combinedErrs = append(combinedErrs, err.Error())
-    }
-    if len(combinedErrs) > 0 {
-        return fmt.Errorf("linting failed:\n%s", strings.Join(combinedErrs, "\n"))
-    }
+    // Collect results while the workers run so they never block on a full channel.
+    var reported []result
+    collected := make(chan struct{})
+    go func() {
+        for r := range results {
+            reported = append(reported, r)
+        }
+        close(collected)
+    }()
 
-    return nil
-}
+    scanner := bufio.NewScanner(stdout)
+    scanner.Buffer(make([]byte, 0, 64*1024), 16*1024*1024) // Max 16MB commit message
+    scanner.Split(splitNUL)
 
-// LintCommitMessage checks if the commit message adheres to predefined rules.
-func LintCommitMessage(message string) error {
-    // Example: Ensure the subject (first line) is non-empty and under 100 characters.
-    lines := strings.SplitN(message, "\n", 2)
-    subject := strings.TrimSpace(lines[0])
-    if len(subject) == 0 {
-        return fmt.Errorf("%s", "commit subject cannot be empty")
-    }
-    if len(subject) > 100 {
-        return fmt.Errorf("%s", "commit subject is too long (max 100 characters)")
-    }
-    // Example: Optionally enforce that the commit message starts with a valid type.
-    validTypes := []string{"feat", "fix", "docs", "style", "refactor", "perf", "test", "chore", "revert", "WIP"}
-    valid := false
-    for _, t := range validTypes {
-        if strings.HasPrefix(subject, t) {
-            valid = true
-            break
+    count := 0
+    for scanner.Scan() {
+        rec, ok := parseCommitRecord(scanner.Text())
+        if !ok {
+            continue
+        }
+        jobs <- job{count, rec}
+        count++
+        if opts.Progress && count%1000 == 0 {
+            fmt.Fprintf(os.Stderr, "\rLinting commits: %d", count)
         }
     }
+    close(jobs)
+    wg.Wait()
+    close(results)
+    <-collected
 
-    if !valid {
-        return fmt.Errorf("commit subject must start with one of the following types: %v", validTypes)
-    }
-
-    return nil
-}
-
-// LintCurrentCommitMessage lints the current commit messages.
-func LintCurrentCommitMessage() error {
-    // Get the latest commit message from HEAD.
-    cmd := exec.Command("git", "log", "-1", "--pretty=%B")
-    output, err := cmd.Output()
-    if err != nil {
-        return fmt.Errorf("failed to get commit message: %v", err)
+    if err := scanner.Err(); err != nil {
+        cmd.Process.Kill()
+        cmd.Wait()
+        return fmt.Errorf("failed to read commit history: %v", err)
     }
-    message := strings.TrimSpace(string(output))
-    // Lint the commit message using LintCommitMessage.
-    return LintCommitMessage(message)
-}
-
-// LintAllCommitMessage lints all commit messages.
-func LintAllCommitMessage() error {
-    // Get all commit hashes from the repository.
-    cmd := exec.Command("git", "log", "--pretty=%H")
-    output, err := cmd.Output()
-    if err != nil {
-        return fmt.Errorf("failed to get commit hashes: %v", err)
+    if err := cmd.Wait(); err != nil {
+        return fmt.Errorf("failed to run git log: %v: %s", err, strings.TrimSpace(stderr.String()))
     }
-    hashes := strings.Split(strings.TrimSpace(string(output)), "\n")
-    if len(hashes) == 0 {
-        return nil
+    if opts.Progress {
+        fmt.Fprintf(os.Stderr, "\rLinted %d commits.\n", count)
     }
 
+    sort.Slice(reported, func(i, j int) bool { return reported[i].index < reported[j].index })
     var combinedErrors []string
-    // Iterate over each commit hash.
-    for _, hash := range hashes {
-        // Get the commit message for this commit.
-        cmdMsg := exec.Command("git", "log", "-1", "--pretty=%B", hash)
-        msgOut, err := cmdMsg.Output()
-        if err != nil {
-            combinedErrors = append(combinedErrors, fmt.Sprintf("failed to get commit message for %s: %v", utils.Color(hash, "red"), err))
+    for _, r := range reported {
+        if r.reason != "" {
+            printSkipped(r.hash, r.reason)
             continue
         }
-        message := strings.TrimSpace(string(msgOut))
-        if err := LintCommitMessage(message); err != nil {
-            combinedErrors = append(combinedErrors, fmt.Sprintf("commit %s: %v", utils.Color(hash, "red"), err))
-        }
+        combinedErrors = append(combinedErrors, r.text)
     }
     if len(combinedErrors) > 0 {
         return fmt.Errorf("linting errors found:\n%s", strings.Join(combinedErrors, "\n"))
@@ -218,23 +251,16 @@ func LintAllCommitMessage() error {
 }
 
 // LintSingleMessage lints a provided commit message string.
+// Only the ignore rules that look at the message itself can apply here.
 func LintSingleMessage(message string) error {
-    return LintCommitMessage(message)
-}
-
-// shouldSkipFile determines if a file should be excluded from linting.
-func shouldSkipFile(file string) bool {
-    base := filepath.Base(file)
-
-    // Skip gommitizen binary (adjust the name if your output path changes!)
-    if file == "gommitizen" || base == "gommitizen" {
-        return true
+    cfg := loadConfigOrDefault()
+    ignorer, err := newLintIgnorer(cfg.Lint.Ignore)
+    if err != nil {
+        return err
     }
-
-    // Skip hidden files (optional)
-    if strings.HasPrefix(base, ".") {
-        return true
+    if reason := ignorer.reason(commitRecord{message: message}); reason != "" {
+        printSkipped("message", reason)
+        return nil
     }
-
-    return false
+    return LintCommitMessage(cfg, message)
 }
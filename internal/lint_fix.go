@@ -0,0 +1,159 @@
+package internal
+
+import (
+    "bufio"
+    "fmt"
+    "os"
+    "os/exec"
+    "strings"
+
+    "gommitizen/internal/utils"
+)
+
+// scissorsLine marks the start of the diff appended by "git commit --verbose"; git drops everything below it.
+const scissorsLine = "# ------------------------ >8 ------------------------"
+
+// splitMessageFile separates a commit message file into the message and the comment lines git strips.
+func splitMessageFile(content string) (message, comments string) {
+    var msgLines, commentLines []string
+    lines := strings.Split(content, "\n")
+    for i, line := range lines {
+        if line == scissorsLine {
+            commentLines = append(commentLines, lines[i:]...)
+            break
+        }
+        if strings.HasPrefix(line, "#") {
+            commentLines = append(commentLines, line)
+            continue
+        }
+        msgLines = append(msgLines, line)
+    }
+    return strings.TrimSpace(strings.Join(msgLines, "\n")), strings.Join(commentLines, "\n")
+}
+
+// readMessageFile loads a commit message file, such as the one passed to the commit-msg hook.
+func readMessageFile(path string) (message, comments string, err error) {
+    data, err := os.ReadFile(path)
+    if err != nil {
+        return "", "", fmt.Errorf("failed to read message file %s: %v", path, err)
+    }
+    message, comments = splitMessageFile(string(data))
+    return message, comments, nil
+}
+
+// LintMessageFile lints the commit message stored in a file.
+func LintMessageFile(path string) error {
+    message, _, err := readMessageFile(path)
+    if err != nil {
+        return err
+    }
+    return LintSingleMessage(message)
+}
+
+// FixSingleMessage returns the message with every automatic fix applied,
+// along with an error describing the issues that are left.
+func FixSingleMessage(message string) (string, error) {
+    linter, err := newCommitLinter(loadConfigOrDefault())
+    if err != nil {
+        return "", err
+    }
+    fixed := linter.fix(message)
+    return fixed, issuesError(linter.lint(fixed))
+}
+
+// FixMessageFile applies the automatic fixes to a commit message file in place.
+// Comment lines are kept after the message so git can still strip them.
+func FixMessageFile(path string) error {
+    message, comments, err := readMessageFile(path)
+    if err != nil {
+        return err
+    }
+
+    cfg := loadConfigOrDefault()
+    ignorer, err := newLintIgnorer(cfg.Lint.Ignore)
+    if err != nil {
+        return err
+    }
+    if reason := ignorer.reason(commitRecord{message: message}); reason != "" {
+        printSkipped("message", reason)
+        return nil
+    }
+
+    linter, err := newCommitLinter(cfg)
+    if err != nil {
+        return err
+    }
+    fixed := linter.fix(message)
+    if fixed != message {
+        content := fixed + "\n"
+        if comments != "" {
+            content += "\n" + comments + "\n"
+        }
+        info, err := os.Stat(path)
+        if err != nil {
+            return fmt.Errorf("failed to stat message file %s: %v", path, err)
+        }
+        if err := os.WriteFile(path, []byte(content), info.Mode().Perm()); err != nil {
+            return fmt.Errorf("failed to write message file %s: %v", path, err)
+        }
+        fmt.Print(utils.StdoutDiff(utils.UnifiedDiff(path, path, message, fixed)))
+    }
+    return issuesError(linter.lint(fixed))
+}
+
+// FixCurrentCommitMessage shows the fixes for the HEAD commit message and,
+// once confirmed, rewords HEAD with "git commit --amend". Ignored commits are left alone.
+func FixCurrentCommitMessage(assumeYes bool) error {
+    cfg := loadConfigOrDefault()
+    ignorer, err := newLintIgnorer(cfg.Lint.Ignore)
+    if err != nil {
+        return err
+    }
+
+    out, err := exec.Command("git", "log", "-1", "-z", "--pretty=format:"+commitRecordFormat).Output()
+    if err != nil {
+        return fmt.Errorf("failed to get commit message: %v", err)
+    }
+    rec, ok := parseCommitRecord(strings.TrimRight(string(out), "\x00"))
+    if !ok {
+        return fmt.Errorf("failed to get commit message: empty output")
+    }
+    if reason := ignorer.reason(rec); reason != "" {
+        printSkipped(rec.hash, reason)
+        return nil
+    }
+    message := strings.TrimSpace(rec.message)
+
+    linter, err := newCommitLinter(cfg)
+    if err != nil {
+        return err
+    }
+    fixed := linter.fix(message)
+    if fixed == message {
+        fmt.Println("Nothing to fix automatically.")
+        return issuesError(linter.lint(message))
+    }
+
+    fmt.Print(utils.StdoutDiff(utils.UnifiedDiff("HEAD", "HEAD (fixed)", message, fixed)))
+
+    if !assumeYes {
+        fmt.Print(utils.Color("Amend HEAD with the fixed message? [y/N]: ", "green"))
+        answer, err := bufio.NewReader(os.Stdin).ReadString('\n')
+        if err != nil && answer == "" {
+            return fmt.Errorf("failed to read confirmation: %v", err)
+        }
+        answer = strings.ToLower(strings.TrimSpace(answer))
+        if answer != "y" && answer != "yes" {
+            fmt.Println("HEAD left unchanged.")
+            return issuesError(linter.lint(message))
+        }
+    }
+
+    // --only without paths rewords HEAD without picking up staged changes.
+    cmd := exec.Command("git", "commit", "--amend", "--only", "--allow-empty", "-m", fixed)
+    if output, err := cmd.CombinedOutput(); err != nil {
+        return fmt.Errorf("failed to amend HEAD: %v\n%s", err, output)
+    }
+    fmt.Println("HEAD reworded.")
+    return issuesError(linter.lint(fixed))
+}
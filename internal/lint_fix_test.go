@@ -0,0 +1,180 @@
+package internal
+
+import (
+    "os"
+    "path/filepath"
+    "reflect"
+    "strings"
+    "testing"
+)
+
+// newTestLinter returns a linter with the default rules, without a word list or secret scanning.
+func newTestLinter(t *testing.T) *commitLinter {
+    t.Helper()
+    cfg := LoadDefaultConfig()
+    cfg.Lint.Subject.WordsFile = ""
+    cfg.Secrets.ScanMessages = false
+    linter, err := newCommitLinter(cfg)
+    if err != nil {
+        t.Fatalf("newCommitLinter() error = %v", err)
+    }
+    return linter
+}
+
+func TestSplitMessageFile(t *testing.T) {
+    tests := []struct {
+        name         string
+        content      string
+        wantMessage  string
+        wantComments string
+    }{
+        {
+            name:         "message without comments",
+            content:      "feat: add login\n\nbody\n",
+            wantMessage:  "feat: add login\n\nbody",
+            wantComments: "",
+        },
+        {
+            name:         "comment lines are separated",
+            content:      "fix: handle nil\n# Please enter the commit message\n# On branch main\n",
+            wantMessage:  "fix: handle nil",
+            wantComments: "# Please enter the commit message\n# On branch main",
+        },
+        {
+            name:         "everything below the scissors line is kept as is",
+            content:      "docs: update readme\n\n" + scissorsLine + "\ndiff --git a/README.md b/README.md\n+new line",
+            wantMessage:  "docs: update readme",
+            wantComments: scissorsLine + "\ndiff --git a/README.md b/README.md\n+new line",
+        },
+    }
+
+    for _, tt := range tests {
+        t.Run(tt.name, func(t *testing.T) {
+            message, comments := splitMessageFile(tt.content)
+            if message != tt.wantMessage {
+                t.Errorf("message = %q, want %q", message, tt.wantMessage)
+            }
+            if comments != tt.wantComments {
+                t.Errorf("comments = %q, want %q", comments, tt.wantComments)
+            }
+        })
+    }
+}
+
+func TestCommitLinterFix(t *testing.T) {
+    tests := []struct {
+        name    string
+        message string
+        want    string
+    }{
+        {
+            name:    "valid message is unchanged",
+            message: "feat(api): add login endpoint\n\nAllow users to sign in.",
+            want:    "feat(api): add login endpoint\n\nAllow users to sign in.",
+        },
+        {
+            name:    "type alias",
+            message: "feature: add login",
+            want:    "feat: add login",
+        },
+        {
+            name:    "type case",
+            message: "FIX: handle nil config",
+            want:    "fix: handle nil config",
+        },
+        {
+            name:    "subject full stop",
+            message: "docs: update readme.",
+            want:    "docs: update readme",
+        },
+        {
+            name:    "body leading blank",
+            message: "fix: handle nil config\nThe config may be missing.",
+            want:    "fix: handle nil config\n\nThe config may be missing.",
+        },
+        {
+            name:    "long body line is wrapped",
+            message: "fix: handle nil config\n\n" + strings.Repeat("word ", 30),
+            want: "fix: handle nil config\n\n" + strings.TrimSpace(strings.Repeat("word ", 20)) + "\n" +
+                strings.TrimSpace(strings.Repeat("word ", 10)),
+        },
+        {
+            name:    "several fixes at once",
+            message: "Bugfix: handle nil config.\nThe config may be missing.",
+            want:    "fix: handle nil config\n\nThe config may be missing.",
+        },
+    }
+
+    linter := newTestLinter(t)
+    for _, tt := range tests {
+        t.Run(tt.name, func(t *testing.T) {
+            if got := linter.fix(tt.message); got != tt.want {
+                t.Errorf("fix() = %q, want %q", got, tt.want)
+            }
+        })
+    }
+}
+
+func TestWrapLine(t *testing.T) {
+    url := "https://example.com/" + strings.Repeat("a", 30)
+    tests := []struct {
+        name  string
+        line  string
+        width int
+        want  []string
+    }{
+        {"short line", "short line", 20, []string{"short line"}},
+        {"wrapped at word boundaries", "one two three four five", 10, []string{"one two", "three four", "five"}},
+        {"indentation is kept", "  one two three four", 10, []string{"  one two", "  three", "  four"}},
+        {"long word gets its own line", "see " + url + " for details", 20, []string{"see", url, "for details"}},
+        {"single long word is not split", url, 20, []string{url}},
+    }
+
+    for _, tt := range tests {
+        t.Run(tt.name, func(t *testing.T) {
+            if got := wrapLine(tt.line, tt.width); !reflect.DeepEqual(got, tt.want) {
+                t.Errorf("wrapLine(%q, %d) = %q, want %q", tt.line, tt.width, got, tt.want)
+            }
+        })
+    }
+}
+
+func TestCheckBodyMaxLineLengthSkipsUnbreakableWords(t *testing.T) {
+    linter := newTestLinter(t)
+    url := "https://example.com/" + strings.Repeat("a", 120)
+    message := "docs: link the design\n\n" + url
+    if issues := checkBodyMaxLineLength(linter, strings.Split(message, "\n")); len(issues) != 0 {
+        t.Errorf("checkBodyMaxLineLength() = %v, want no issues", issues)
+    }
+}
+
+func TestFixMessageFile(t *testing.T) {
+    dir := t.TempDir()
+    path := filepath.Join(dir, "COMMIT_EDITMSG")
+    content := "feature: add login.\n# Please enter the commit message\n" + scissorsLine + "\ndiff --git a/x b/x\n"
+    if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+        t.Fatal(err)
+    }
+
+    // Run from the temporary directory, so that no repository config or word list is picked up.
+    wd, err := os.Getwd()
+    if err != nil {
+        t.Fatal(err)
+    }
+    if err := os.Chdir(dir); err != nil {
+        t.Fatal(err)
+    }
+    defer os.Chdir(wd)
+
+    if err := FixMessageFile(path); err != nil {
+        t.Fatalf("FixMessageFile() error = %v", err)
+    }
+    data, err := os.ReadFile(path)
+    if err != nil {
+        t.Fatal(err)
+    }
+    want := "feat: add login\n\n# Please enter the commit message\n" + scissorsLine + "\ndiff --git a/x b/x\n\n"
+    if string(data) != want {
+        t.Errorf("file content = %q, want %q", data, want)
+    }
+}
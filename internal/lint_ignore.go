@@ -0,0 +1,171 @@
+package internal
+
+import (
+    "bufio"
+    "fmt"
+    "os"
+    "path/filepath"
+    "regexp"
+    "strings"
+)
+
+// ignoreRule is a predicate exempting a commit from message linting.
+type ignoreRule struct {
+    reason string
+    match  func(rec commitRecord) bool
+}
+
+// botAuthorRegexp matches the authors used by common dependency updaters and CI bots.
+var botAuthorRegexp = regexp.MustCompile(`(?i)\[bot\]|^(dependabot|renovate|greenkeeper|snyk-bot|github-actions|pre-commit-ci)\b`)
+
+// builtinIgnoreRules exempt commits written by git itself or by tooling.
+var builtinIgnoreRules = []ignoreRule{
+    {
+        reason: "merge commit",
+        match: func(rec commitRecord) bool {
+            return len(rec.parents) > 1 || strings.HasPrefix(commitHeader(rec.message), "Merge ")
+        },
+    },
+    {
+        reason: "revert commit",
+        match: func(rec commitRecord) bool {
+            return strings.HasPrefix(commitHeader(rec.message), `Revert "`)
+        },
+    },
+    {
+        reason: "fixup/squash commit",
+        match: func(rec commitRecord) bool {
+            header := commitHeader(rec.message)
+            return strings.HasPrefix(header, "fixup! ") ||
+                strings.HasPrefix(header, "squash! ") ||
+                strings.HasPrefix(header, "amend! ")
+        },
+    },
+    {
+        reason: "bot commit",
+        match: func(rec commitRecord) bool {
+            return rec.author != "" && botAuthorRegexp.MatchString(rec.author)
+        },
+    },
+}
+
+// lintIgnorer decides which commits are skipped by the linter.
+type lintIgnorer struct {
+    rules []ignoreRule
+}
+
+// newLintIgnorer builds the ignore rules from the built-in predicates and the config.
+func newLintIgnorer(cfg IgnoreConfig) (*lintIgnorer, error) {
+    ig := &lintIgnorer{}
+    if cfg.Builtin {
+        ig.rules = append(ig.rules, builtinIgnoreRules...)
+    }
+
+    for _, pat := range cfg.Headers {
+        re, err := regexp.Compile(pat)
+        if err != nil {
+            return nil, fmt.Errorf("invalid ignore header pattern %q: %v", pat, err)
+        }
+        ig.rules = append(ig.rules, ignoreRule{
+            reason: fmt.Sprintf("header matches %q", pat),
+            match: func(rec commitRecord) bool {
+                return re.MatchString(commitHeader(rec.message))
+            },
+        })
+    }
+
+    for _, pat := range cfg.Authors {
+        re, err := regexp.Compile(pat)
+        if err != nil {
+            return nil, fmt.Errorf("invalid ignore author pattern %q: %v", pat, err)
+        }
+        ig.rules = append(ig.rules, ignoreRule{
+            reason: fmt.Sprintf("author matches %q", pat),
+            match: func(rec commitRecord) bool {
+                return rec.author != "" && re.MatchString(rec.author)
+            },
+        })
+    }
+
+    if cfg.HashesFile != "" {
+        hashes, err := readIgnoredHashes(cfg.HashesFile)
+        if err != nil {
+            return nil, err
+        }
+        if len(hashes) > 0 {
+            file := cfg.HashesFile
+            ig.rules = append(ig.rules, ignoreRule{
+                reason: fmt.Sprintf("listed in %s", file),
+                match: func(rec commitRecord) bool {
+                    for _, h := range hashes {
+                        if rec.hash != "" && strings.HasPrefix(rec.hash, h) {
+                            return true
+                        }
+                    }
+                    return false
+                },
+            })
+        }
+    }
+
+    return ig, nil
+}
+
+// reason returns why the commit is skipped, or an empty string if it must be linted.
+func (ig *lintIgnorer) reason(rec commitRecord) string {
+    for _, rule := range ig.rules {
+        if rule.match(rec) {
+            return rule.reason
+        }
+    }
+    return ""
+}
+
+// readIgnoredHashes reads commit hashes (one per line, "#" comments allowed) from a file.
+// Relative paths are resolved against the repository root; a missing file, or a relative
+// path outside a repository, yields no hashes.
+func readIgnoredHashes(path string) ([]string, error) {
+    if !filepath.IsAbs(path) {
+        root, err := gitRoot()
+        if err != nil {
+            // Outside a repository there is no ignore file to read.
+            return nil, nil
+        }
+        path = filepath.Join(root, path)
+    }
+
+    f, err := os.Open(path)
+    if os.IsNotExist(err) {
+        return nil, nil
+    }
+    if err != nil {
+        return nil, fmt.Errorf("failed to open ignore file %s: %v", path, err)
+    }
+    defer f.Close()
+
+    var hashes []string
+    scanner := bufio.NewScanner(f)
+    for scanner.Scan() {
+        line := scanner.Text()
+        if idx := strings.Index(line, "#"); idx != -1 {
+            line = line[:idx]
+        }
+        line = strings.ToLower(strings.TrimSpace(line))
+        if line == "" {
+            continue
+        }
+        if len(line) < 7 {
+            return nil, fmt.Errorf("ignore file %s: hash %q is too short (min 7 characters)", path, line)
+        }
+        hashes = append(hashes, line)
+    }
+    if err := scanner.Err(); err != nil {
+        return nil, fmt.Errorf("failed to read ignore file %s: %v", path, err)
+    }
+    return hashes, nil
+}
+
+// commitHeader returns the first line of a commit message.
+func commitHeader(message string) string {
+    return strings.TrimSpace(strings.SplitN(message, "\n", 2)[0])
+}
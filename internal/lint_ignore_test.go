@@ -0,0 +1,161 @@
+package internal
+
+import (
+    "os"
+    "path/filepath"
+    "reflect"
+    "testing"
+)
+
+func TestLintIgnorerReason(t *testing.T) {
+    ig, err := newLintIgnorer(IgnoreConfig{
+        Builtin: true,
+        Headers: []string{`^release: `},
+        Authors: []string{`@build\.example\.com>$`},
+    })
+    if err != nil {
+        t.Fatalf("newLintIgnorer() error = %v", err)
+    }
+
+    tests := []struct {
+        name string
+        rec  commitRecord
+        want string
+    }{
+        {"regular commit", commitRecord{message: "feat: add login", author: "Jane Doe <jane@example.com>"}, ""},
+        {"merge by parents", commitRecord{message: "feat: add login", parents: []string{"a", "b"}}, "merge commit"},
+        {"merge by header", commitRecord{message: "Merge branch 'main' into topic"}, "merge commit"},
+        {"revert", commitRecord{message: "Revert \"feat: add login\"\n\nThis reverts commit abc."}, "revert commit"},
+        {"fixup", commitRecord{message: "fixup! feat: add login"}, "fixup/squash commit"},
+        {"squash", commitRecord{message: "squash! feat: add login"}, "fixup/squash commit"},
+        {"amend", commitRecord{message: "amend! feat: add login"}, "fixup/squash commit"},
+        {"bot suffix", commitRecord{message: "Bump foo", author: "dependabot[bot] <bot@example.com>"}, "bot commit"},
+        {"bot name", commitRecord{message: "Update deps", author: "renovate <bot@example.com>"}, "bot commit"},
+        {"header pattern", commitRecord{message: "release: 1.2.0"}, `header matches "^release: "`},
+        {"author pattern", commitRecord{message: "Nightly", author: "CI <ci@build.example.com>"}, `author matches "@build\\.example\\.com>$"`},
+        {"lower-case revert is linted", commitRecord{message: "revert: feat: add login"}, ""},
+    }
+
+    for _, tt := range tests {
+        t.Run(tt.name, func(t *testing.T) {
+            if got := ig.reason(tt.rec); got != tt.want {
+                t.Errorf("reason() = %q, want %q", got, tt.want)
+            }
+        })
+    }
+}
+
+func TestLintIgnorerWithoutBuiltin(t *testing.T) {
+    ig, err := newLintIgnorer(IgnoreConfig{})
+    if err != nil {
+        t.Fatalf("newLintIgnorer() error = %v", err)
+    }
+    if got := ig.reason(commitRecord{message: "Merge branch 'main'", parents: []string{"a", "b"}}); got != "" {
+        t.Errorf("reason() = %q, want \"\"", got)
+    }
+}
+
+func TestNewLintIgnorerInvalidPatterns(t *testing.T) {
+    tests := []struct {
+        name string
+        cfg  IgnoreConfig
+    }{
+        {"header", IgnoreConfig{Headers: []string{"("}}},
+        {"author", IgnoreConfig{Authors: []string{"[bot"}}},
+    }
+
+    for _, tt := range tests {
+        t.Run(tt.name, func(t *testing.T) {
+            if _, err := newLintIgnorer(tt.cfg); err == nil {
+                t.Error("newLintIgnorer() error = nil, want an error")
+            }
+        })
+    }
+}
+
+func TestReadIgnoredHashes(t *testing.T) {
+    dir := t.TempDir()
+    write := func(name, content string) string {
+        path := filepath.Join(dir, name)
+        if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+            t.Fatal(err)
+        }
+        return path
+    }
+
+    tests := []struct {
+        name    string
+        path    string
+        want    []string
+        wantErr bool
+    }{
+        {
+            name: "hashes and comments",
+            path: write("revs", "# reformatting\nABCDEF1234567890\n\n1234567 # typo fix\n"),
+            want: []string{"abcdef1234567890", "1234567"},
+        },
+        {
+            name:    "short hash",
+            path:    write("short", "abc123\n"),
+            wantErr: true,
+        },
+        {
+            name: "missing file",
+            path: filepath.Join(dir, "missing"),
+            want: nil,
+        },
+    }
+
+    for _, tt := range tests {
+        t.Run(tt.name, func(t *testing.T) {
+            got, err := readIgnoredHashes(tt.path)
+            if (err != nil) != tt.wantErr {
+                t.Fatalf("readIgnoredHashes() error = %v, wantErr %v", err, tt.wantErr)
+            }
+            if !reflect.DeepEqual(got, tt.want) {
+                t.Errorf("readIgnoredHashes() = %v, want %v", got, tt.want)
+            }
+        })
+    }
+}
+
+func TestReadIgnoredHashesOutsideRepository(t *testing.T) {
+    dir := t.TempDir()
+    if err := os.WriteFile(filepath.Join(dir, "revs"), []byte("1234567\n"), 0o600); err != nil {
+        t.Fatal(err)
+    }
+    wd, err := os.Getwd()
+    if err != nil {
+        t.Fatal(err)
+    }
+    if err := os.Chdir(dir); err != nil {
+        t.Fatal(err)
+    }
+    defer os.Chdir(wd)
+
+    got, err := readIgnoredHashes("revs")
+    if err != nil || got != nil {
+        t.Errorf("readIgnoredHashes() = %v, %v, want nil, nil", got, err)
+    }
+}
+
+func TestIgnoredHashPrefix(t *testing.T) {
+    path := filepath.Join(t.TempDir(), "revs")
+    if err := os.WriteFile(path, []byte("1234567\n"), 0o600); err != nil {
+        t.Fatal(err)
+    }
+    ig, err := newLintIgnorer(IgnoreConfig{HashesFile: path})
+    if err != nil {
+        t.Fatalf("newLintIgnorer() error = %v", err)
+    }
+
+    if got := ig.reason(commitRecord{hash: "1234567890abcdef", message: "wip"}); got != "listed in "+path {
+        t.Errorf("reason() = %q, want %q", got, "listed in "+path)
+    }
+    if got := ig.reason(commitRecord{hash: "7654321890abcdef", message: "wip"}); got != "" {
+        t.Errorf("reason() = %q, want \"\"", got)
+    }
+    if got := ig.reason(commitRecord{message: "wip"}); got != "" {
+        t.Errorf("reason() for a message without a hash = %q, want \"\"", got)
+    }
+}
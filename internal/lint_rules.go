@@ -0,0 +1,543 @@
+package internal
+
+import (
+    "context"
+    "fmt"
+    "regexp"
+    "sort"
+    "strings"
+    "unicode/utf8"
+)
+
+// validTypes are the commit types accepted at the start of the header.
+var validTypes = []string{"feat", "fix", "docs", "style", "refactor", "perf", "test", "chore", "revert", "WIP"}
+
+// headerRegexp splits a conventional commit header into type, scope, breaking marker and subject.
+var headerRegexp = regexp.MustCompile(`^([^\s():!]+)(?:\(([^()]*)\))?(!)?:\s*(.*)$`)
+
+// conventionalHeader is a parsed "type(scope)!: subject" header.
+type conventionalHeader struct {
+    typ      string
+    scope    string
+    breaking bool
+    subject  string
+}
+
+// parseConventionalHeader parses a commit header; ok is false if it has no "type:" prefix.
+func parseConventionalHeader(header string) (conventionalHeader, bool) {
+    m := headerRegexp.FindStringSubmatch(strings.TrimSpace(header))
+    if m == nil {
+        return conventionalHeader{}, false
+    }
+    return conventionalHeader{
+        typ:      m[1],
+        scope:    m[2],
+        breaking: m[3] == "!",
+        subject:  m[4],
+    }, true
+}
+
+// lintIssue is a single rule violation found in a commit message.
+type lintIssue struct {
+    rule string
+    line int // 1-based line number in the message
+    text string
+}
+
+// lintRule checks one aspect of a commit message.
+// Rules whose violations have an unambiguous correction also provide a fixer.
+type lintRule struct {
+    name  string
+    check func(l *commitLinter, lines []string) []lintIssue
+    fix   func(l *commitLinter, lines []string) []string
+}
+
+// commitLinter runs the lint rules with a given configuration.
+type commitLinter struct {
+    cfg       LintConfig
+    rules     []lintRule
+    words     subjectWords      // Verb forms and names known to the subject checks
+    secrets   *secretScanner    // Scans the message for secrets; nil when disabled
+}
+
+// newCommitLinter creates a linter with the built-in rules.
+func newCommitLinter(cfg Config) (*commitLinter, error) {
+    words, err := loadSubjectWords(cfg.Lint.Subject)
+    if err != nil {
+        return nil, err
+    }
+    linter := &commitLinter{cfg: cfg.Lint, rules: messageRules, words: words}
+    if cfg.Secrets.ScanMessages {
+        linter.secrets, err = newSecretScanner(cfg.Secrets, true)
+        if err != nil {
+            return nil, err
+        }
+    }
+    return linter, nil
+}
+
+// lint returns every issue found in the message.
+func (l *commitLinter) lint(message string) []lintIssue {
+    lines := strings.Split(message, "\n")
+    var issues []lintIssue
+    for _, rule := range l.rules {
+        issues = append(issues, rule.check(l, lines)...)
+    }
+    sort.SliceStable(issues, func(i, j int) bool { return issues[i].line < issues[j].line })
+    return issues
+}
+
+// fix applies every available fixer to the message and returns the corrected message.
+// Issues without a fixer are left in place.
+func (l *commitLinter) fix(message string) string {
+    lines := strings.Split(message, "\n")
+    for _, rule := range l.rules {
+        if rule.fix == nil || len(rule.check(l, lines)) == 0 {
+            continue
+        }
+        lines = rule.fix(l, lines)
+    }
+    return strings.Join(lines, "\n")
+}
+
+// issuesError combines lint issues into a single error, or returns nil if there are none.
+func issuesError(issues []lintIssue) error {
+    if len(issues) == 0 {
+        return nil
+    }
+    texts := make([]string, len(issues))
+    for i, issue := range issues {
+        texts[i] = fmt.Sprintf("line %d: %s", issue.line, issue.text)
+    }
+    return fmt.Errorf("%s", strings.Join(texts, "; "))
+}
+
+// =======================
+// Header Rules
+// =======================
+
+// messageRules are the built-in lint rules, in the order they are checked and fixed.
+var messageRules = []lintRule{
+    {name: "header-empty", check: checkHeaderEmpty},
+    {name: "header-max-length", check: checkHeaderMaxLength},
+    {name: "type-alias", check: checkTypeAlias, fix: fixTypeAlias},
+    {name: "type-case", check: checkTypeCase, fix: fixTypeCase},
+    {name: "type-enum", check: checkTypeEnum},
+    {name: "subject-full-stop", check: checkSubjectFullStop, fix: fixSubjectFullStop},
+    {name: "subject-imperative", check: checkSubjectImperative, fix: fixSubjectImperative},
+    {name: "subject-case", check: checkSubjectCase, fix: fixSubjectCase},
+    {name: "body-leading-blank", check: checkBodyLeadingBlank, fix: fixBodyLeadingBlank},
+    {name: "body-max-line-length", check: checkBodyMaxLineLength, fix: fixBodyMaxLineLength},
+    {name: "breaking-change-spelling", check: checkBreakingChangeSpelling, fix: fixBreakingChangeSpelling},
+    {name: "footer-trailer", check: checkFooterTrailers},
+    {name: "message-secret", check: checkMessageSecrets},
+}
+
+func checkHeaderEmpty(l *commitLinter, lines []string) []lintIssue {
+    if strings.TrimSpace(lines[0]) == "" {
+        return []lintIssue{{"header-empty", 1, "commit subject cannot be empty"}}
+    }
+    return nil
+}
+
+func checkHeaderMaxLength(l *commitLinter, lines []string) []lintIssue {
+    if utf8.RuneCountInString(strings.TrimSpace(lines[0])) > l.cfg.HeaderMaxLength {
+        return []lintIssue{{"header-max-length", 1,
+            fmt.Sprintf("commit subject is too long (max %d characters)", l.cfg.HeaderMaxLength)}}
+    }
+    return nil
+}
+
+// canonicalType returns the accepted spelling of a type that differs only in case.
+func canonicalType(typ string) (string, bool) {
+    for _, t := range validTypes {
+        if strings.EqualFold(t, typ) {
+            return t, true
+        }
+    }
+    return "", false
+}
+
+// isValidType reports whether typ is exactly one of the accepted types.
+func isValidType(typ string) bool {
+    for _, t := range validTypes {
+        if t == typ {
+            return true
+        }
+    }
+    return false
+}
+
+// typeAlias returns the accepted type a misspelled type stands for.
+func (l *commitLinter) typeAlias(typ string) (string, bool) {
+    if isValidType(typ) {
+        return "", false
+    }
+    alias, ok := l.cfg.TypeAliases[strings.ToLower(typ)]
+    return alias, ok && alias != ""
+}
+
+// replaceHeaderType swaps the type in the header line for another one.
+func replaceHeaderType(lines []string, typ string) []string {
+    header := strings.TrimSpace(lines[0])
+    h, ok := parseConventionalHeader(header)
+    if !ok {
+        return lines
+    }
+    lines[0] = typ + strings.TrimPrefix(header, h.typ)
+    return lines
+}
+
+func checkTypeAlias(l *commitLinter, lines []string) []lintIssue {
+    h, ok := parseConventionalHeader(lines[0])
+    if !ok {
+        return nil
+    }
+    if alias, ok := l.typeAlias(h.typ); ok {
+        return []lintIssue{{"type-alias", 1, fmt.Sprintf("type %q should be %q", h.typ, alias)}}
+    }
+    return nil
+}
+
+func fixTypeAlias(l *commitLinter, lines []string) []string {
+    h, _ := parseConventionalHeader(lines[0])
+    alias, _ := l.typeAlias(h.typ)
+    return replaceHeaderType(lines, alias)
+}
+
+func checkTypeCase(l *commitLinter, lines []string) []lintIssue {
+    h, ok := parseConventionalHeader(lines[0])
+    if !ok || isValidType(h.typ) {
+        return nil
+    }
+    if _, isAlias := l.typeAlias(h.typ); isAlias {
+        return nil
+    }
+    if canonical, ok := canonicalType(h.typ); ok {
+        return []lintIssue{{"type-case", 1, fmt.Sprintf("type %q should be written %q", h.typ, canonical)}}
+    }
+    return nil
+}
+
+func fixTypeCase(l *commitLinter, lines []string) []string {
+    h, _ := parseConventionalHeader(lines[0])
+    canonical, _ := canonicalType(h.typ)
+    return replaceHeaderType(lines, canonical)
+}
+
+func checkTypeEnum(l *commitLinter, lines []string) []lintIssue {
+    if strings.TrimSpace(lines[0]) == "" {
+        return nil
+    }
+    h, ok := parseConventionalHeader(lines[0])
+    if ok {
+        if _, isAlias := l.typeAlias(h.typ); isAlias {
+            return nil
+        }
+        if _, ok := canonicalType(h.typ); ok {
+            return nil
+        }
+    }
+    return []lintIssue{{"type-enum", 1,
+        fmt.Sprintf("commit subject must start with one of the following types: %v", validTypes)}}
+}
+
+func checkSubjectFullStop(l *commitLinter, lines []string) []lintIssue {
+    h, ok := parseConventionalHeader(lines[0])
+    if ok && strings.HasSuffix(h.subject, ".") {
+        return []lintIssue{{"subject-full-stop", 1, "subject must not end with a period"}}
+    }
+    return nil
+}
+
+func fixSubjectFullStop(l *commitLinter, lines []string) []string {
+    lines[0] = strings.TrimRight(strings.TrimSpace(lines[0]), ".")
+    return lines
+}
+
+// =======================
+// Body Rules
+// =======================
+
+func checkBodyLeadingBlank(l *commitLinter, lines []string) []lintIssue {
+    if len(lines) > 1 && strings.TrimSpace(lines[1]) != "" {
+        return []lintIssue{{"body-leading-blank", 2, "missing blank line between header and body"}}
+    }
+    return nil
+}
+
+func fixBodyLeadingBlank(l *commitLinter, lines []string) []string {
+    return append([]string{lines[0], ""}, lines[1:]...)
+}
+
+// checkBodyMaxLineLength reports the body lines that are too long and can be wrapped. A line
+// holding a single word longer than the limit, such as a URL or a hash, is exempt.
+func checkBodyMaxLineLength(l *commitLinter, lines []string) []lintIssue {
+    var issues []lintIssue
+    for i := 1; i < footerStart(lines); i++ {
+        if n := utf8.RuneCountInString(lines[i]); n > l.cfg.BodyMaxLineLength && len(wrapLine(lines[i], l.cfg.BodyMaxLineLength)) > 1 {
+            issues = append(issues, lintIssue{"body-max-line-length", i + 1,
+                fmt.Sprintf("body line is too long (%d > %d characters)", n, l.cfg.BodyMaxLineLength)})
+        }
+    }
+    return issues
+}
+
+func fixBodyMaxLineLength(l *commitLinter, lines []string) []string {
+    footer := footerStart(lines)
+    fixed := []string{lines[0]}
+    for _, line := range lines[1:footer] {
+        fixed = append(fixed, wrapLine(line, l.cfg.BodyMaxLineLength)...)
+    }
+    return append(fixed, lines[footer:]...)
+}
+
+// wrapLine breaks a line at word boundaries so that each part fits in width,
+// keeping the original indentation. Words longer than width (e.g. URLs) are never split
+// and get a line of their own.
+func wrapLine(line string, width int) []string {
+    if utf8.RuneCountInString(line) <= width {
+        return []string{line}
+    }
+    indent := line[:len(line)-len(strings.TrimLeft(line, " \t"))]
+    words := strings.Fields(line)
+
+    var wrapped []string
+    current := indent
+    for _, word := range words {
+        if current != indent && utf8.RuneCountInString(current)+1+utf8.RuneCountInString(word) > width {
+            wrapped = append(wrapped, current)
+            current = indent
+        }
+        if current != indent {
+            current += " "
+        }
+        current += word
+    }
+    return append(wrapped, current)
+}
+
+// =======================
+// Footer Rules
+// =======================
+
+// trailerRegexp matches a git trailer line: "Token: value" or "Token #value".
+var trailerRegexp = regexp.MustCompile(`^(BREAKING CHANGE|[A-Za-z][A-Za-z0-9-]*)(: | #)(.*)$`)
+
+// breakingChangeRegexp matches the variants people write for a breaking change footer.
+var breakingChangeRegexp = regexp.MustCompile(`(?i)^breaking[ _-]?changes?\s*(:|#)`)
+
+// breakingTokenRegexp matches the two spellings the spec allows, followed by the trailer separator.
+var breakingTokenRegexp = regexp.MustCompile(`^BREAKING[ -]CHANGE(?:: | #)`)
+
+// personRegexp matches a "Name <email>" trailer value.
+var personRegexp = regexp.MustCompile(`^[^<>]+ <[^<>\s@]+@[^<>\s]+>$`)
+
+// issueRefRegexp matches one issue reference: "#12", "owner/repo#12", "PROJ-12" or a URL.
+var issueRefRegexp = regexp.MustCompile(`^(([\w.-]+/[\w.-]+)?#\d+|[A-Z][A-Z0-9]+-\d+|https?://\S+)$`)
+
+// trailer is a parsed "Token: value" footer line.
+type trailer struct {
+    token string
+    value string
+    line  int // 1-based line number in the message
+}
+
+// parseTrailer parses a single trailer line. Values given as "Token #value" keep their "#".
+func parseTrailer(line string) (trailer, bool) {
+    m := trailerRegexp.FindStringSubmatch(line)
+    if m == nil {
+        return trailer{}, false
+    }
+    value := m[3]
+    if m[2] == " #" {
+        value = "#" + value
+    }
+    return trailer{token: m[1], value: strings.TrimSpace(value)}, true
+}
+
+// trailerValidators check the values of well-known trailer tokens (keyed in lower case).
+var trailerValidators = map[string]func(value string) string{
+    "co-authored-by": validatePerson,
+    "signed-off-by":  validatePerson,
+    "reviewed-by":    validatePerson,
+    "acked-by":       validatePerson,
+    "tested-by":      validatePerson,
+    "reported-by":    validatePerson,
+    "suggested-by":   validatePerson,
+    "closes":         validateIssueRefs,
+    "fixes":          validateIssueRefs,
+    "resolves":       validateIssueRefs,
+    "refs":           validateIssueRefs,
+    "breaking change": func(value string) string {
+        if value == "" {
+            return "must describe the breaking change"
+        }
+        return ""
+    },
+}
+
+func validatePerson(value string) string {
+    if !personRegexp.MatchString(value) {
+        return "must be \"Name <email>\""
+    }
+    return ""
+}
+
+func validateIssueRefs(value string) string {
+    for _, ref := range splitIssueRefs(value) {
+        if !issueRefRegexp.MatchString(ref) {
+            return fmt.Sprintf("has an invalid issue reference %q (expected #123, owner/repo#123, PROJ-123 or a URL)", ref)
+        }
+    }
+    return ""
+}
+
+// splitIssueRefs splits a trailer value listing several issues separated by commas or whitespace.
+func splitIssueRefs(value string) []string {
+    return strings.FieldsFunc(value, func(r rune) bool {
+        return r == ',' || r == ' ' || r == '\t' || r == '\n'
+    })
+}
+
+// isBreakingToken reports whether a trailer token announces a breaking change.
+func isBreakingToken(token string) bool {
+    return token == "BREAKING CHANGE" || token == "BREAKING-CHANGE"
+}
+
+// footerStart returns the index of the first line of the footer block, or len(lines) if there is none.
+// The footer is the last paragraph of the body when it starts with a trailer and either contains a
+// well-known token or consists of trailers only.
+func footerStart(lines []string) int {
+    end := len(lines)
+    for end > 1 && strings.TrimSpace(lines[end-1]) == "" {
+        end--
+    }
+    start := end
+    for start > 1 && strings.TrimSpace(lines[start-1]) != "" {
+        start--
+    }
+    // The header paragraph is never a footer.
+    if start < 2 || start >= end {
+        return len(lines)
+    }
+    if _, ok := parseTrailer(lines[start]); !ok {
+        return len(lines)
+    }
+
+    known, allTrailers := false, true
+    for _, line := range lines[start:end] {
+        if strings.HasPrefix(line, " ") || strings.HasPrefix(line, "\t") {
+            continue // continuation of the previous trailer
+        }
+        t, ok := parseTrailer(line)
+        if !ok {
+            allTrailers = false
+            continue
+        }
+        if _, ok := trailerValidators[strings.ToLower(t.token)]; ok || isBreakingToken(t.token) {
+            known = true
+        }
+    }
+    if !known && !allTrailers {
+        return len(lines)
+    }
+    return start
+}
+
+// parseFooter returns the trailers of the footer block. Continuation lines are folded into
+// the previous value; lines that are neither are returned as invalid line numbers.
+func parseFooter(lines []string) (trailers []trailer, invalid []int) {
+    for i := footerStart(lines); i < len(lines); i++ {
+        line := lines[i]
+        if strings.TrimSpace(line) == "" {
+            continue
+        }
+        if (strings.HasPrefix(line, " ") || strings.HasPrefix(line, "\t")) && len(trailers) > 0 {
+            trailers[len(trailers)-1].value += "\n" + strings.TrimSpace(line)
+            continue
+        }
+        t, ok := parseTrailer(line)
+        if !ok {
+            invalid = append(invalid, i+1)
+            continue
+        }
+        t.line = i + 1
+        trailers = append(trailers, t)
+    }
+    return trailers, invalid
+}
+
+func checkBreakingChangeSpelling(l *commitLinter, lines []string) []lintIssue {
+    var issues []lintIssue
+    for i := 1; i < len(lines); i++ {
+        line := lines[i]
+        if breakingChangeRegexp.MatchString(line) && !breakingTokenRegexp.MatchString(line) {
+            issues = append(issues, lintIssue{"breaking-change-spelling", i + 1,
+                "breaking change footer must be spelled \"BREAKING CHANGE:\""})
+        }
+    }
+    return issues
+}
+
+func fixBreakingChangeSpelling(l *commitLinter, lines []string) []string {
+    for i := 1; i < len(lines); i++ {
+        loc := breakingChangeRegexp.FindStringSubmatchIndex(lines[i])
+        if loc == nil || breakingTokenRegexp.MatchString(lines[i]) {
+            continue
+        }
+        rest := strings.TrimSpace(lines[i][loc[1]:])
+        if lines[i][loc[2]:loc[3]] == "#" {
+            rest = "#" + rest
+        }
+        lines[i] = "BREAKING CHANGE: " + rest
+    }
+    return lines
+}
+
+func checkFooterTrailers(l *commitLinter, lines []string) []lintIssue {
+    trailers, invalid := parseFooter(lines)
+    var issues []lintIssue
+    for _, line := range invalid {
+        issues = append(issues, lintIssue{"footer-trailer", line,
+            "footer line is not a valid trailer (expected \"Token: value\" or \"Token #value\")"})
+    }
+    for _, t := range trailers {
+        token := t.token
+        if token == "BREAKING-CHANGE" {
+            token = "BREAKING CHANGE"
+        }
+        validate, ok := trailerValidators[strings.ToLower(token)]
+        if !ok {
+            continue
+        }
+        if problem := validate(t.value); problem != "" {
+            issues = append(issues, lintIssue{"footer-trailer", t.line, fmt.Sprintf("%s %s", t.token, problem)})
+        }
+    }
+    return issues
+}
+
+// =======================
+// Secret Rules
+// =======================
+
+// messageSecretPath is the file name the secret detectors see for a commit message.
+const messageSecretPath = "COMMIT_EDITMSG"
+
+func checkMessageSecrets(l *commitLinter, lines []string) []lintIssue {
+    if l.secrets == nil {
+        return nil
+    }
+    content := []byte(strings.Join(lines, "\n"))
+    findings, err := l.secrets.detect(context.Background(), scanTarget{path: messageSecretPath, content: content})
+    if err != nil {
+        return []lintIssue{{"message-secret", 1, fmt.Sprintf("failed to scan the message for secrets: %v", err)}}
+    }
+    var issues []lintIssue
+    for _, f := range findings {
+        issues = append(issues, lintIssue{"message-secret", f.Line,
+            fmt.Sprintf("message contains a secret at column %d: %s (%s, rule: %s)", f.Column, f.Snippet, f.Description, f.RuleID)})
+    }
+    return issues
+}
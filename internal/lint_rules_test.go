@@ -0,0 +1,181 @@
+package internal
+
+import (
+    "reflect"
+    "strings"
+    "testing"
+)
+
+func TestParseTrailer(t *testing.T) {
+    tests := []struct {
+        line   string
+        want   trailer
+        wantOK bool
+    }{
+        {"Signed-off-by: Jane Doe <jane@example.com>", trailer{token: "Signed-off-by", value: "Jane Doe <jane@example.com>"}, true},
+        {"Closes #12", trailer{token: "Closes", value: "#12"}, true},
+        {"BREAKING CHANGE: config keys are renamed", trailer{token: "BREAKING CHANGE", value: "config keys are renamed"}, true},
+        {"BREAKING-CHANGE: config keys are renamed", trailer{token: "BREAKING-CHANGE", value: "config keys are renamed"}, true},
+        {"Just a sentence: with a colon", trailer{}, false},
+        {"no separator here", trailer{}, false},
+    }
+
+    for _, tt := range tests {
+        t.Run(tt.line, func(t *testing.T) {
+            got, ok := parseTrailer(tt.line)
+            if ok != tt.wantOK || got != tt.want {
+                t.Errorf("parseTrailer(%q) = %+v, %v, want %+v, %v", tt.line, got, ok, tt.want, tt.wantOK)
+            }
+        })
+    }
+}
+
+func TestFooterStart(t *testing.T) {
+    tests := []struct {
+        name    string
+        message string
+        want    int
+    }{
+        {"header only", "feat: add login", 1},
+        {"body without footer", "feat: add login\n\nAllow users to sign in.", 3},
+        {"trailers only", "feat: add login\n\nAllow users to sign in.\n\nCloses #12\nSigned-off-by: Jane Doe <jane@example.com>", 4},
+        {"custom trailers only", "feat: add login\n\nAllow users to sign in.\n\nX-Ticket: 42", 4},
+        {"known token with a continuation", "feat: add login\n\nBREAKING CHANGE: the session\n  cookie is renamed", 2},
+        {"trailing blank lines", "feat: add login\n\nCloses #12\n\n", 2},
+        {"paragraph that only starts like a trailer", "feat: add login\n\nNote: this is prose\nand more prose.", 4},
+        {"header followed by a trailer line", "feat: add login\nCloses #12", 2},
+    }
+
+    for _, tt := range tests {
+        t.Run(tt.name, func(t *testing.T) {
+            if got := footerStart(strings.Split(tt.message, "\n")); got != tt.want {
+                t.Errorf("footerStart() = %d, want %d", got, tt.want)
+            }
+        })
+    }
+}
+
+func TestParseFooter(t *testing.T) {
+    message := "feat: add login\n\nBody.\n\nBREAKING CHANGE: the session\n  cookie is renamed\nnot a trailer\nRefs #3"
+    trailers, invalid := parseFooter(strings.Split(message, "\n"))
+
+    wantTrailers := []trailer{
+        {token: "BREAKING CHANGE", value: "the session\ncookie is renamed", line: 5},
+        {token: "Refs", value: "#3", line: 8},
+    }
+    if !reflect.DeepEqual(trailers, wantTrailers) {
+        t.Errorf("trailers = %+v, want %+v", trailers, wantTrailers)
+    }
+    if want := []int{7}; !reflect.DeepEqual(invalid, want) {
+        t.Errorf("invalid = %v, want %v", invalid, want)
+    }
+}
+
+func TestTrailerValidators(t *testing.T) {
+    tests := []struct {
+        token   string
+        value   string
+        wantErr bool
+    }{
+        {"signed-off-by", "Jane Doe <jane@example.com>", false},
+        {"co-authored-by", "jane@example.com", true},
+        {"reviewed-by", "Jane Doe", true},
+        {"closes", "#12", false},
+        {"fixes", "owner/repo#12, PROJ-7", false},
+        {"refs", "https://example.com/issues/12", false},
+        {"closes", "12", true},
+        {"resolves", "#12 proj-7", true},
+        {"breaking change", "", true},
+        {"breaking change", "config keys are renamed", false},
+    }
+
+    for _, tt := range tests {
+        t.Run(tt.token+" "+tt.value, func(t *testing.T) {
+            problem := trailerValidators[tt.token](tt.value)
+            if (problem != "") != tt.wantErr {
+                t.Errorf("validator(%q) = %q, wantErr %v", tt.value, problem, tt.wantErr)
+            }
+        })
+    }
+}
+
+func TestBreakingTokenRegexp(t *testing.T) {
+    tests := []struct {
+        line string
+        want bool
+    }{
+        {"BREAKING CHANGE: keys are renamed", true},
+        {"BREAKING-CHANGE: keys are renamed", true},
+        {"BREAKING CHANGE #12", true},
+        {"BREAKING CHANGES: keys are renamed", false},
+        {"BREAKING CHANGE : keys are renamed", false},
+        {"Breaking change: keys are renamed", false},
+        {"BREAKING_CHANGE: keys are renamed", false},
+    }
+
+    for _, tt := range tests {
+        t.Run(tt.line, func(t *testing.T) {
+            if got := breakingTokenRegexp.MatchString(tt.line); got != tt.want {
+                t.Errorf("breakingTokenRegexp.MatchString(%q) = %v, want %v", tt.line, got, tt.want)
+            }
+        })
+    }
+}
+
+func TestBreakingChangeSpelling(t *testing.T) {
+    tests := []struct {
+        name      string
+        footer    string
+        wantIssue bool
+        wantFixed string
+    }{
+        {"spec spelling", "BREAKING CHANGE: keys are renamed", false, "BREAKING CHANGE: keys are renamed"},
+        {"hyphenated token", "BREAKING-CHANGE: keys are renamed", false, "BREAKING-CHANGE: keys are renamed"},
+        {"hash separator", "BREAKING CHANGE #12", false, "BREAKING CHANGE #12"},
+        {"plural", "BREAKING CHANGES: keys are renamed", true, "BREAKING CHANGE: keys are renamed"},
+        {"space before colon", "BREAKING CHANGE : keys are renamed", true, "BREAKING CHANGE: keys are renamed"},
+        {"lower case", "breaking change: keys are renamed", true, "BREAKING CHANGE: keys are renamed"},
+        {"underscore with hash", "Breaking_Change #12", true, "BREAKING CHANGE: #12"},
+    }
+
+    linter := newTestLinter(t)
+    for _, tt := range tests {
+        t.Run(tt.name, func(t *testing.T) {
+            lines := []string{"feat: rename keys", "", tt.footer}
+            issues := checkBreakingChangeSpelling(linter, lines)
+            if (len(issues) > 0) != tt.wantIssue {
+                t.Errorf("checkBreakingChangeSpelling() = %v, wantIssue %v", issues, tt.wantIssue)
+            }
+            if got := linter.fix(strings.Join(lines, "\n")); got != "feat: rename keys\n\n"+tt.wantFixed {
+                t.Errorf("fix() = %q, want footer %q", got, tt.wantFixed)
+            }
+        })
+    }
+}
+
+func TestCheckFooterTrailers(t *testing.T) {
+    tests := []struct {
+        name      string
+        message   string
+        wantLines []int
+    }{
+        {"valid footer", "fix: handle nil\n\nBody.\n\nCloses #12\nSigned-off-by: Jane Doe <jane@example.com>", nil},
+        {"invalid person", "fix: handle nil\n\nBody.\n\nSigned-off-by: jane", []int{5}},
+        {"invalid issue reference", "fix: handle nil\n\nBody.\n\nCloses #12\nFixes: twelve", []int{6}},
+        {"line that is not a trailer", "fix: handle nil\n\nBody.\n\nCloses #12\nsee above", []int{6}},
+        {"empty breaking change", "feat!: rename keys\n\nBREAKING CHANGE: ", []int{3}},
+    }
+
+    linter := newTestLinter(t)
+    for _, tt := range tests {
+        t.Run(tt.name, func(t *testing.T) {
+            var lines []int
+            for _, issue := range checkFooterTrailers(linter, strings.Split(tt.message, "\n")) {
+                lines = append(lines, issue.line)
+            }
+            if !reflect.DeepEqual(lines, tt.wantLines) {
+                t.Errorf("issue lines = %v, want %v", lines, tt.wantLines)
+            }
+        })
+    }
+}
@@ -0,0 +1,279 @@
+package internal
+
+import (
+    "bufio"
+    "fmt"
+    "os"
+    "path/filepath"
+    "regexp"
+    "strings"
+    "unicode"
+)
+
+// imperativeVerbs are the verbs commonly used to start a subject. Their third-person,
+// past and gerund forms ("adds", "added", "adding") are derived by nonImperativeForms.
+var imperativeVerbs = []string{
+    "add", "adjust", "allow", "apply", "avoid", "build", "bump", "cache", "change", "check",
+    "clean", "configure", "convert", "copy", "correct", "create", "delete", "deprecate", "detect",
+    "disable", "display", "document", "drop", "emit", "enable", "ensure", "export", "expose",
+    "extract", "filter", "fix", "format", "generate", "handle", "hide", "ignore", "implement",
+    "import", "improve", "include", "initialize", "install", "introduce", "limit", "link",
+    "load", "make", "mark", "merge", "migrate", "move", "optimize", "parse", "pass", "prevent",
+    "print", "publish", "raise", "refactor", "refresh", "register", "reject", "release",
+    "remove", "rename", "render", "reorganize", "repair", "replace", "report", "require", "reset",
+    "resolve", "restore", "restrict", "retry", "return", "reuse", "revert", "rewrite", "run",
+    "save", "scan", "send", "show", "simplify", "skip", "sort", "split", "stop", "store", "strip",
+    "support", "switch", "tweak", "uninstall", "unify", "update", "upgrade", "use", "validate",
+    "verify", "wrap", "write",
+}
+
+// irregularForms are non-imperative forms that the suffix rules do not produce.
+var irregularForms = map[string]string{
+    "made":    "make",
+    "built":   "build",
+    "wrote":   "write",
+    "written": "write",
+    "ran":     "run",
+    "sent":    "send",
+}
+
+// nounPlurals are third-person forms that are also plural nouns, as in "links in changelog" or
+// "changes to README". They are not flagged, since the subject may start with the noun.
+var nounPlurals = map[string]bool{
+    "builds": true, "caches": true, "changes": true, "checks": true, "displays": true, "documents": true,
+    "exports": true, "filters": true, "formats": true, "handles": true, "imports": true, "limits": true,
+    "links": true, "merges": true, "refactors": true, "releases": true, "repairs": true, "reports": true,
+    "retries": true, "returns": true, "reverts": true, "rewrites": true, "runs": true, "scans": true,
+    "splits": true, "switches": true, "tweaks": true, "updates": true, "upgrades": true, "uses": true,
+}
+
+// doubledFinal lists the verbs whose final consonant doubles before "-ed" and "-ing".
+var doubledFinal = map[string]bool{
+    "drop": true, "skip": true, "split": true, "stop": true, "wrap": true, "strip": true, "run": true,
+}
+
+// thisCommitRegexp matches subjects that describe the commit instead of the change.
+var thisCommitRegexp = regexp.MustCompile(`(?i)^this (commit|change|patch|pr|mr|pull request)\b`)
+
+// nonImperativeForms derives the third-person, past and gerund forms of a verb.
+func nonImperativeForms(verb string) []string {
+    var forms []string
+    last := verb[len(verb)-1]
+    consonantY := last == 'y' && len(verb) > 1 && !strings.ContainsRune("aeiou", rune(verb[len(verb)-2]))
+
+    // Third person: "fixes", "applies", "adds".
+    switch {
+    case strings.HasSuffix(verb, "s"), strings.HasSuffix(verb, "x"), strings.HasSuffix(verb, "z"),
+        strings.HasSuffix(verb, "ch"), strings.HasSuffix(verb, "sh"):
+        forms = append(forms, verb+"es")
+    case consonantY:
+        forms = append(forms, verb[:len(verb)-1]+"ies")
+    default:
+        forms = append(forms, verb+"s")
+    }
+
+    // Past and gerund: "added"/"adding", "updated"/"updating", "applied"/"applying", "dropped"/"dropping".
+    switch {
+    case doubledFinal[verb]:
+        doubled := verb + string(last)
+        if verb != "run" {
+            forms = append(forms, doubled+"ed")
+        }
+        forms = append(forms, doubled+"ing")
+    case last == 'e':
+        forms = append(forms, verb+"d", verb[:len(verb)-1]+"ing")
+    case consonantY:
+        forms = append(forms, verb[:len(verb)-1]+"ied", verb+"ing")
+    default:
+        forms = append(forms, verb+"ed", verb+"ing")
+    }
+    return forms
+}
+
+// builtinVerbForms maps each known non-imperative form to its imperative, except the noun plurals.
+func builtinVerbForms() map[string]string {
+    forms := make(map[string]string)
+    for _, verb := range imperativeVerbs {
+        for _, form := range nonImperativeForms(verb) {
+            if !nounPlurals[form] {
+                forms[form] = verb
+            }
+        }
+    }
+    for form, verb := range irregularForms {
+        forms[form] = verb
+    }
+    return forms
+}
+
+// subjectWords are the words the subject checks know besides the built-in verbs.
+type subjectWords struct {
+    verbForms map[string]string // Non-imperative word -> imperative form; "" flags without a suggestion
+    names     map[string]bool   // Proper nouns and product names that may start a subject capitalized
+}
+
+// loadSubjectWords combines the built-in verb forms with the config and the repository word list.
+func loadSubjectWords(cfg SubjectConfig) (subjectWords, error) {
+    words := subjectWords{verbForms: builtinVerbForms(), names: make(map[string]bool)}
+    forms := words.verbForms
+    for word, verb := range cfg.Verbs {
+        forms[strings.ToLower(word)] = verb
+    }
+    for _, name := range cfg.Names {
+        words.names[name] = true
+    }
+    if cfg.WordsFile == "" {
+        return words, nil
+    }
+
+    path := cfg.WordsFile
+    if !filepath.IsAbs(path) {
+        root, err := gitRoot()
+        if err != nil {
+            // Outside a repository there is no repository word list to read.
+            return words, nil
+        }
+        path = filepath.Join(root, path)
+    }
+    f, err := os.Open(path)
+    if os.IsNotExist(err) {
+        return words, nil
+    }
+    if err != nil {
+        return subjectWords{}, fmt.Errorf("failed to open word list %s: %v", path, err)
+    }
+    defer f.Close()
+
+    // Each line is "<word> [imperative]"; a word without an imperative is flagged without a suggestion,
+    // unless it is capitalized, as in "Linux": then it is a name that may start a subject.
+    scanner := bufio.NewScanner(f)
+    for scanner.Scan() {
+        line := scanner.Text()
+        if idx := strings.Index(line, "#"); idx != -1 {
+            line = line[:idx]
+        }
+        fields := strings.Fields(line)
+        switch len(fields) {
+        case 0:
+            continue
+        case 1:
+            if isCapitalized(fields[0]) {
+                words.names[fields[0]] = true
+                continue
+            }
+            forms[strings.ToLower(fields[0])] = ""
+        default:
+            forms[strings.ToLower(fields[0])] = fields[1]
+        }
+    }
+    if err := scanner.Err(); err != nil {
+        return subjectWords{}, fmt.Errorf("failed to read word list %s: %v", path, err)
+    }
+    return words, nil
+}
+
+// firstWord returns the first word of a subject, without surrounding punctuation.
+func firstWord(subject string) string {
+    fields := strings.Fields(subject)
+    if len(fields) == 0 {
+        return ""
+    }
+    return strings.TrimFunc(fields[0], func(r rune) bool {
+        return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+    })
+}
+
+// =======================
+// Subject Rules
+// =======================
+
+func checkSubjectImperative(l *commitLinter, lines []string) []lintIssue {
+    if !l.cfg.Subject.Imperative {
+        return nil
+    }
+    h, ok := parseConventionalHeader(lines[0])
+    if !ok {
+        return nil
+    }
+    if thisCommitRegexp.MatchString(h.subject) {
+        return []lintIssue{{"subject-imperative", 1,
+            "subject should say what the change does in imperative mood (e.g. \"add ...\"), not \"this commit ...\""}}
+    }
+
+    word := firstWord(h.subject)
+    verb, ok := l.words.verbForms[strings.ToLower(word)]
+    if !ok {
+        return nil
+    }
+    if verb == "" {
+        return []lintIssue{{"subject-imperative", 1, fmt.Sprintf("subject should start with an imperative verb, not %q", word)}}
+    }
+    return []lintIssue{{"subject-imperative", 1, fmt.Sprintf("subject should use imperative mood: %q instead of %q", verb, word)}}
+}
+
+func fixSubjectImperative(l *commitLinter, lines []string) []string {
+    h, _ := parseConventionalHeader(lines[0])
+    word := firstWord(h.subject)
+    verb := l.words.verbForms[strings.ToLower(word)]
+    if verb == "" || word == "" || thisCommitRegexp.MatchString(h.subject) {
+        return lines
+    }
+    if unicode.IsUpper([]rune(word)[0]) {
+        verb = strings.ToUpper(verb[:1]) + verb[1:]
+    }
+    prefix := strings.TrimSuffix(strings.TrimSpace(lines[0]), h.subject)
+    lines[0] = prefix + strings.Replace(h.subject, word, verb, 1)
+    return lines
+}
+
+// isCapitalized reports whether a word is written "Like this" (and not "LIKE", "GitHub" or "iOS").
+func isCapitalized(word string) bool {
+    runes := []rune(word)
+    if len(runes) == 0 || !unicode.IsUpper(runes[0]) {
+        return false
+    }
+    for _, r := range runes[1:] {
+        if unicode.IsUpper(r) {
+            return false
+        }
+    }
+    return true
+}
+
+func checkSubjectCase(l *commitLinter, lines []string) []lintIssue {
+    if !l.cfg.Subject.LowerCase {
+        return nil
+    }
+    h, ok := parseConventionalHeader(lines[0])
+    if word := firstWord(h.subject); ok && isCapitalized(word) && !l.words.names[word] {
+        return []lintIssue{{"subject-case", 1, "subject must start with a lower-case letter"}}
+    }
+    return nil
+}
+
+// isKnownVerb reports whether a lower-case word is a verb the subject checks know, in any form.
+func (l *commitLinter) isKnownVerb(word string) bool {
+    if _, ok := l.words.verbForms[word]; ok {
+        return true
+    }
+    for _, verb := range imperativeVerbs {
+        if verb == word {
+            return true
+        }
+    }
+    return false
+}
+
+// fixSubjectCase lowers the first letter only when the first word is a known verb, so that
+// proper nouns such as "Go" or "Linux" are reported but never rewritten.
+func fixSubjectCase(l *commitLinter, lines []string) []string {
+    h, _ := parseConventionalHeader(lines[0])
+    runes := []rune(h.subject)
+    if len(runes) == 0 || !l.isKnownVerb(strings.ToLower(firstWord(h.subject))) {
+        return lines
+    }
+    runes[0] = unicode.ToLower(runes[0])
+    prefix := strings.TrimSuffix(strings.TrimSpace(lines[0]), h.subject)
+    lines[0] = prefix + string(runes)
+    return lines
+}
@@ -0,0 +1,162 @@
+package internal
+
+import (
+    "os"
+    "path/filepath"
+    "reflect"
+    "strings"
+    "testing"
+)
+
+func TestNonImperativeForms(t *testing.T) {
+    tests := []struct {
+        verb string
+        want []string
+    }{
+        {"add", []string{"adds", "added", "adding"}},
+        {"fix", []string{"fixes", "fixed", "fixing"}},
+        {"update", []string{"updates", "updated", "updating"}},
+        {"apply", []string{"applies", "applied", "applying"}},
+        {"display", []string{"displays", "displayed", "displaying"}},
+        {"drop", []string{"drops", "dropped", "dropping"}},
+        {"run", []string{"runs", "running"}},
+        {"switch", []string{"switches", "switched", "switching"}},
+    }
+
+    for _, tt := range tests {
+        t.Run(tt.verb, func(t *testing.T) {
+            if got := nonImperativeForms(tt.verb); !reflect.DeepEqual(got, tt.want) {
+                t.Errorf("nonImperativeForms(%q) = %v, want %v", tt.verb, got, tt.want)
+            }
+        })
+    }
+}
+
+func TestBuiltinVerbForms(t *testing.T) {
+    forms := builtinVerbForms()
+    tests := []struct {
+        word     string
+        want     string
+        wantForm bool
+    }{
+        {"adds", "add", true},
+        {"fixes", "fix", true},
+        {"added", "add", true},
+        {"updating", "update", true},
+        {"made", "make", true},
+        {"written", "write", true},
+        {"updates", "", false},
+        {"links", "", false},
+        {"changes", "", false},
+        {"add", "", false},
+    }
+
+    for _, tt := range tests {
+        t.Run(tt.word, func(t *testing.T) {
+            got, ok := forms[tt.word]
+            if ok != tt.wantForm || got != tt.want {
+                t.Errorf("forms[%q] = %q, %v, want %q, %v", tt.word, got, ok, tt.want, tt.wantForm)
+            }
+        })
+    }
+}
+
+func TestCheckSubjectImperative(t *testing.T) {
+    tests := []struct {
+        header    string
+        wantIssue bool
+        wantFixed string
+    }{
+        {"feat: add login", false, "feat: add login"},
+        {"feat: adds login", true, "feat: add login"},
+        {"fix(api): fixed nil config", true, "fix(api): fix nil config"},
+        {"feat: updates to the readme", false, "feat: updates to the readme"},
+        {"feat: this commit adds login", true, "feat: this commit adds login"},
+        {"docs: this change documents flags", true, "docs: this change documents flags"},
+    }
+
+    linter := newTestLinter(t)
+    for _, tt := range tests {
+        t.Run(tt.header, func(t *testing.T) {
+            issues := checkSubjectImperative(linter, []string{tt.header})
+            if (len(issues) > 0) != tt.wantIssue {
+                t.Errorf("checkSubjectImperative() = %v, wantIssue %v", issues, tt.wantIssue)
+            }
+            if got := linter.fix(tt.header); got != tt.wantFixed {
+                t.Errorf("fix() = %q, want %q", got, tt.wantFixed)
+            }
+        })
+    }
+}
+
+func TestCheckSubjectCase(t *testing.T) {
+    tests := []struct {
+        header    string
+        wantIssue bool
+        wantFixed string
+    }{
+        {"feat: add login", false, "feat: add login"},
+        {"feat: Add login", true, "feat: add login"},
+        {"fix: Fixed nil config", true, "fix: fix nil config"},
+        {"chore: Go 1.22 is required", true, "chore: Go 1.22 is required"},
+        {"docs: GitHub actions setup", false, "docs: GitHub actions setup"},
+        {"docs: README wording", false, "docs: README wording"},
+        {"build: Linux packages", false, "build: Linux packages"},
+    }
+
+    linter := newTestLinter(t)
+    linter.words.names["Linux"] = true
+    for _, tt := range tests {
+        t.Run(tt.header, func(t *testing.T) {
+            issues := checkSubjectCase(linter, []string{tt.header})
+            if (len(issues) > 0) != tt.wantIssue {
+                t.Errorf("checkSubjectCase() = %v, wantIssue %v", issues, tt.wantIssue)
+            }
+            if got := linter.fix(tt.header); got != tt.wantFixed {
+                t.Errorf("fix() = %q, want %q", got, tt.wantFixed)
+            }
+        })
+    }
+}
+
+func TestLoadSubjectWords(t *testing.T) {
+    path := filepath.Join(t.TempDir(), "words")
+    content := strings.Join([]string{
+        "# project words",
+        "bumped bump",
+        "tidied   # flagged without a suggestion",
+        "Kubernetes",
+        "",
+    }, "\n")
+    if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+        t.Fatal(err)
+    }
+
+    words, err := loadSubjectWords(SubjectConfig{
+        Verbs:     map[string]string{"Shipped": "ship"},
+        Names:     []string{"Go"},
+        WordsFile: path,
+    })
+    if err != nil {
+        t.Fatalf("loadSubjectWords() error = %v", err)
+    }
+
+    wantForms := map[string]string{"bumped": "bump", "tidied": "", "shipped": "ship", "adds": "add"}
+    for word, want := range wantForms {
+        if got, ok := words.verbForms[word]; !ok || got != want {
+            t.Errorf("verbForms[%q] = %q, %v, want %q", word, got, ok, want)
+        }
+    }
+    for _, name := range []string{"Go", "Kubernetes"} {
+        if !words.names[name] {
+            t.Errorf("names[%q] = false, want true", name)
+        }
+    }
+    if _, ok := words.verbForms["kubernetes"]; ok {
+        t.Error("capitalized word was loaded as a verb form")
+    }
+
+    if _, err := loadSubjectWords(SubjectConfig{WordsFile: filepath.Join(t.TempDir(), "missing")}); err != nil {
+        t.Errorf("loadSubjectWords() with a missing file error = %v, want nil", err)
+    }
+}
@@ -0,0 +1,349 @@
+package internal
+
+import (
+    "context"
+    "fmt"
+    "math"
+    "regexp"
+    "sort"
+    "strings"
+)
+
+// Finding is a secret detected in scanned content.
+type Finding struct {
+    RuleID      string  `json:"ruleId"`
+    Description string  `json:"description"`
+    Path        string  `json:"path"`
+    Commit      string  `json:"commit,omitempty"` // Commit that introduced the secret, for history scans
+    Line        int     `json:"line"`             // 1-based line in the file; detectors report the line in the content they scan
+    Column      int     `json:"column"`           // 1-based byte column of the secret
+    Snippet     string  `json:"snippet"`          // The secret with its middle masked, e.g. "AKIA*************XYZ"
+    Entropy     float64 `json:"entropy,omitempty"`
+    Fingerprint string  `json:"fingerprint"` // Stable ID of rule, file and secret, used by the baseline
+    Secret      string  `json:"-"`           // The matched secret; never printed or written out
+}
+
+// String formats the finding like a compiler diagnostic, "path:line:col: rule-id: ...",
+// so editors and terminals can jump to it. The secret itself is never included.
+func (f Finding) String() string {
+    s := fmt.Sprintf("%s:%d:%d: %s: %s: %s [%s]", f.Path, f.Line, f.Column, f.RuleID, f.Description, f.Snippet, f.Fingerprint)
+    if f.Commit != "" {
+        s += fmt.Sprintf(" (commit %.12s)", f.Commit)
+    }
+    return s
+}
+
+// redact masks a secret, keeping only a few characters at each end so it can be recognized.
+// Short secrets are masked entirely.
+func redact(secret string) string {
+    runes := []rune(secret)
+    var prefix, suffix int
+    switch n := len(runes); {
+    case n >= 16:
+        prefix, suffix = 4, 3
+    case n >= 10:
+        prefix, suffix = 2, 2
+    default:
+        return strings.Repeat("*", n)
+    }
+    masked := strings.Repeat("*", len(runes)-prefix-suffix)
+    return string(runes[:prefix]) + masked + string(runes[len(runes)-suffix:])
+}
+
+// secretRule is a detection rule with a stable ID.
+type secretRule struct {
+    id             string
+    description    string
+    regex          *regexp.Regexp
+    keywords       []string // Lower-case strings one of which must appear in the line for the regex to run; none means always
+    secretGroup    int      // First capture group that may hold the secret; 0 means the whole match
+    minEntropy     float64  // Minimum Shannon entropy of the secret; 0 disables the check
+    charsetEntropy bool     // Apply the configured per-charset entropy thresholds to the secret
+}
+
+// secretRules is the built-in rule pack. Rule IDs are stable and used in suppressions and baselines.
+var secretRules = []secretRule{
+    {
+        id:          "aws-access-key-id",
+        description: "AWS access key ID",
+        regex:       regexp.MustCompile(`\b((?:AKIA|ASIA|ABIA|ACCA)[0-9A-Z]{16})\b`),
+        keywords:    []string{"akia", "asia", "abia", "acca"},
+        secretGroup: 1,
+        minEntropy:  3.0,
+    },
+    {
+        id:          "aws-secret-access-key",
+        description: "AWS secret access key",
+        regex:       regexp.MustCompile(`(?i)aws_?secret_?(?:access_?)?key["']?\s*(?:=|:=|:|=>)\s*["']?([A-Za-z0-9/+=]{40})\b`),
+        keywords:    []string{"aws"},
+        secretGroup: 1,
+        minEntropy:  3.5,
+    },
+    {
+        id:          "github-token",
+        description: "GitHub personal access, OAuth, app or refresh token",
+        regex:       regexp.MustCompile(`\b(gh[pousr]_[A-Za-z0-9]{36,255})\b`),
+        keywords:    []string{"ghp_", "gho_", "ghu_", "ghs_", "ghr_"},
+        secretGroup: 1,
+        minEntropy:  3.0,
+    },
+    {
+        id:          "github-fine-grained-token",
+        description: "GitHub fine-grained personal access token",
+        regex:       regexp.MustCompile(`\b(github_pat_[A-Za-z0-9_]{82})\b`),
+        keywords:    []string{"github_pat_"},
+        secretGroup: 1,
+        minEntropy:  3.0,
+    },
+    {
+        id:          "gitlab-token",
+        description: "GitLab personal, project or pipeline trigger token",
+        regex:       regexp.MustCompile(`\b(glpat-[A-Za-z0-9_\-]{20,}|glptt-[0-9a-f]{40})\b`),
+        keywords:    []string{"glpat-", "glptt-"},
+        secretGroup: 1,
+        minEntropy:  3.0,
+    },
+    {
+        id:          "slack-token",
+        description: "Slack bot, user, app or refresh token",
+        regex:       regexp.MustCompile(`\b(xox[abeoprs]-[0-9A-Za-z-]{10,})\b`),
+        keywords:    []string{"xoxa-", "xoxb-", "xoxe-", "xoxo-", "xoxp-", "xoxr-", "xoxs-"},
+        secretGroup: 1,
+        minEntropy:  3.0,
+    },
+    {
+        id:          "slack-webhook-url",
+        description: "Slack incoming webhook URL",
+        regex:       regexp.MustCompile(`(https://hooks\.slack\.com/(?:services|workflows)/[A-Za-z0-9+/_-]{20,})`),
+        keywords:    []string{"hooks.slack.com"},
+        secretGroup: 1,
+    },
+    {
+        id:          "private-key",
+        description: "PEM private key",
+        regex:       regexp.MustCompile(`-----BEGIN (?:[A-Z0-9]+ )*PRIVATE KEY(?: BLOCK)?-----`),
+        keywords:    []string{"private key"},
+    },
+    {
+        id:          "jwt",
+        description: "JSON Web Token",
+        regex:       regexp.MustCompile(`\b(eyJ[A-Za-z0-9_-]{10,}\.eyJ[A-Za-z0-9_-]{10,}\.[A-Za-z0-9_-]{10,})`),
+        keywords:    []string{"eyj"},
+        secretGroup: 1,
+        minEntropy:  3.0,
+    },
+    {
+        id:          "google-api-key",
+        description: "Google API key",
+        regex:       regexp.MustCompile(`\b(AIza[0-9A-Za-z_\-]{35})\b`),
+        keywords:    []string{"aiza"},
+        secretGroup: 1,
+        minEntropy:  3.0,
+    },
+    {
+        id:          "generic-secret-assignment",
+        description: "Secret assigned or passed next to a password, secret, token or key name",
+        // The value is either assigned ("key = value", "key: value") or quoted after the
+        // name in a call or pair ("set_token(\"value\")", "\"api-key\", \"value\"").
+        // An unquoted value must be a single token, so that code such as "token := cfg.Token()"
+        // or "secret := line[start:end]" is not taken for a secret.
+        regex: regexp.MustCompile(`(?i)\b[\w.-]*(?:password|passwd|pwd|secret|api[_-]?key|token|credential|auth[_-]?key|access[_-]?key|private[_-]?key)[\w.-]*["'` + "`" + `]?` +
+            `(?:\s*(?:=|:=|:|=>)\s*(?:["'` + "`" + `]([^\s"'` + "`" + `,;]+)|([^\s"'` + "`" + `,;()\[\]{}.<>]+)(?:[\s,;]|$))` +
+            `|\s*[(,]\s*["'` + "`" + `]([^\s"'` + "`" + `]+)["'` + "`" + `])`),
+        keywords:       []string{"password", "passwd", "pwd", "secret", "key", "token", "credential"},
+        secretGroup:    1,
+        charsetEntropy: true,
+    },
+}
+
+// calculateEntropy computes the Shannon entropy of a string.
+func calculateEntropy(s string) float64 {
+    freq := make(map[rune]float64)
+    for _, r := range s {
+        freq[r]++
+    }
+    var entropy float64
+    l := float64(len(s))
+    for _, count := range freq {
+        p := count / l
+        entropy -= p * math.Log2(p)
+    }
+    return entropy
+}
+
+// hexRegexp and base64Regexp classify the charset of a candidate secret.
+var (
+    hexRegexp    = regexp.MustCompile(`^[0-9a-fA-F]+$`)
+    base64Regexp = regexp.MustCompile(`^[A-Za-z0-9+/_-]+=*$`)
+)
+
+// entropyThreshold returns the configured threshold for the charset of a candidate secret.
+func entropyThreshold(cfg EntropyConfig, secret string) EntropyThreshold {
+    switch {
+    case hexRegexp.MatchString(secret):
+        return cfg.Hex
+    case base64Regexp.MatchString(secret):
+        return cfg.Base64
+    default:
+        return cfg.Generic
+    }
+}
+
+// passesEntropy reports whether a secret is random enough for the rule, and its entropy.
+func (r *secretRule) passesEntropy(secret string, cfg EntropyConfig) (bool, float64) {
+    minEntropy := r.minEntropy
+    if r.charsetEntropy {
+        threshold := entropyThreshold(cfg, secret)
+        if len(secret) < threshold.MinLength {
+            return false, 0
+        }
+        minEntropy = threshold.MinEntropy
+    }
+    if minEntropy <= 0 {
+        return true, 0
+    }
+    entropy := calculateEntropy(secret)
+    return entropy >= minEntropy, entropy
+}
+
+// matchLine returns the rule's findings in a single line.
+func (r *secretRule) matchLine(line string, cfg EntropyConfig) []Finding {
+    var findings []Finding
+    for _, loc := range r.regex.FindAllStringSubmatchIndex(line, -1) {
+        start, end := loc[0], loc[1]
+        if r.secretGroup > 0 {
+            // Use the first alternative group that took part in the match.
+            for g := r.secretGroup; 2*g < len(loc); g++ {
+                if loc[2*g] >= 0 {
+                    start, end = loc[2*g], loc[2*g+1]
+                    break
+                }
+            }
+        }
+        secret := line[start:end]
+
+        ok, entropy := r.passesEntropy(secret, cfg)
+        if !ok {
+            continue
+        }
+        findings = append(findings, Finding{
+            RuleID:      r.id,
+            Description: r.description,
+            Column:      start + 1,
+            Snippet:     redact(secret),
+            Entropy:     entropy,
+            Secret:      secret,
+        })
+    }
+    return findings
+}
+
+// secretScanner runs the detectors with the configured filters and allowlists.
+type secretScanner struct {
+    detectors []namedDetector
+    filter    *scanFilter
+    allowlist *secretAllowlist
+}
+
+// newSecretScanner creates a scanner with the registered and configured detectors.
+// With useBaseline, findings recorded in the baseline file are not reported.
+func newSecretScanner(cfg SecretsConfig, useBaseline bool) (*secretScanner, error) {
+    filter, err := newScanFilter(cfg)
+    if err != nil {
+        return nil, err
+    }
+    allowlist, err := newSecretAllowlist(cfg, useBaseline)
+    if err != nil {
+        return nil, err
+    }
+    detectors, err := newDetectors(cfg)
+    if err != nil {
+        return nil, err
+    }
+    return &secretScanner{detectors: detectors, filter: filter, allowlist: allowlist}, nil
+}
+
+// rules lists the rules of every detector that can list them, in detector order.
+func (s *secretScanner) rules() []DetectorRule {
+    var rules []DetectorRule
+    for _, d := range s.detectors {
+        if lister, ok := d.Detector.(RuleLister); ok {
+            rules = append(rules, lister.Rules()...)
+        }
+    }
+    return rules
+}
+
+// skipsPath reports whether a file is never scanned, so its content need not be read.
+func (s *secretScanner) skipsPath(path string) bool {
+    return s.filter.skipPath(path) || s.allowlist.pathAllowed(path)
+}
+
+// scan runs every detector over the target content, reading it first if needed, and completes
+// their findings with the file, commit, line in the file and fingerprint. Excluded, binary,
+// oversized and Git LFS pointer files are skipped.
+func (s *secretScanner) scan(ctx context.Context, target scanTarget) ([]Finding, error) {
+    if s.skipsPath(target.path) {
+        return nil, nil
+    }
+    if target.content == nil && target.load != nil {
+        if target.size != nil {
+            size, err := target.size()
+            if err != nil {
+                return nil, err
+            }
+            if s.filter.tooLarge(size) {
+                return nil, nil
+            }
+        }
+        content, err := target.load()
+        if err != nil {
+            return nil, err
+        }
+        target.content = content
+    }
+    if s.filter.skipContent(target.content) {
+        return nil, nil
+    }
+    return s.detect(ctx, target)
+}
+
+// detect runs every detector over the target content, without the path and content filters.
+func (s *secretScanner) detect(ctx context.Context, target scanTarget) ([]Finding, error) {
+    var lines []string // Content lines, split once a detector finds something
+    var findings []Finding
+    for _, d := range s.detectors {
+        found, err := d.Detect(ctx, target.path, target.content)
+        if err != nil {
+            return nil, fmt.Errorf("detector %s: %v", d.name, err)
+        }
+        if len(found) > 0 && lines == nil {
+            lines = strings.Split(string(target.content), "\n")
+        }
+        for _, f := range found {
+            if f.Line >= 1 && f.Line <= len(lines) && inlineAllowed(lines[f.Line-1], f.RuleID) {
+                continue
+            }
+            f.Path = target.path
+            f.Commit = target.commit
+            f.Line = target.lineNumber(f.Line)
+            if f.Snippet == "" {
+                f.Snippet = redact(f.Secret)
+            }
+            f.Fingerprint = fingerprint(f.RuleID, f.Path, f.Secret)
+            if s.allowlist.allowed(f) {
+                continue
+            }
+            findings = append(findings, f)
+        }
+    }
+    if len(s.detectors) > 1 {
+        sort.SliceStable(findings, func(i, j int) bool {
+            if findings[i].Line != findings[j].Line {
+                return findings[i].Line < findings[j].Line
+            }
+            return findings[i].Column < findings[j].Column
+        })
+    }
+    return findings, nil
+}
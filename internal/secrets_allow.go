@@ -0,0 +1,231 @@
+package internal
+
+import (
+    "context"
+    "crypto/sha256"
+    "encoding/hex"
+    "encoding/json"
+    "fmt"
+    "os"
+    "path/filepath"
+    "regexp"
+    "sort"
+    "strings"
+)
+
+// inlineAllowRegexp matches a "gommitizen:allow <rule>[,<rule>...]" suppression comment.
+var inlineAllowRegexp = regexp.MustCompile(`gommitizen:allow\s+([\w,-]+)`)
+
+// secretHash returns the SHA-256 of a secret, so it can be stored without the secret itself.
+func secretHash(secret string) string {
+    sum := sha256.Sum256([]byte(secret))
+    return hex.EncodeToString(sum[:])
+}
+
+// fingerprint identifies a finding by rule, file and secret, independent of its line.
+func fingerprint(ruleID, path, secret string) string {
+    sum := sha256.Sum256([]byte(ruleID + "\x00" + path + "\x00" + secretHash(secret)))
+    return hex.EncodeToString(sum[:16])
+}
+
+// inlineAllowed reports whether the line carries a suppression comment for the rule.
+func inlineAllowed(line, ruleID string) bool {
+    for _, m := range inlineAllowRegexp.FindAllStringSubmatch(line, -1) {
+        for _, id := range strings.Split(m[1], ",") {
+            if id == ruleID || id == "all" {
+                return true
+            }
+        }
+    }
+    return false
+}
+
+// =======================
+// Allowlist
+// =======================
+
+// secretAllowlist drops the findings that were explicitly acknowledged.
+type secretAllowlist struct {
+    paths    []*regexp.Regexp
+    secrets  []*regexp.Regexp
+    baseline map[string]bool // Fingerprints of known findings
+}
+
+// newSecretAllowlist compiles the configured allowlists and, with useBaseline, loads the baseline.
+func newSecretAllowlist(cfg SecretsConfig, useBaseline bool) (*secretAllowlist, error) {
+    a := &secretAllowlist{}
+    for _, pat := range cfg.Allowlist.Paths {
+        re, err := regexp.Compile(pat)
+        if err != nil {
+            return nil, fmt.Errorf("invalid allowlist path pattern %q: %v", pat, err)
+        }
+        a.paths = append(a.paths, re)
+    }
+    for _, pat := range cfg.Allowlist.Regexes {
+        re, err := regexp.Compile(pat)
+        if err != nil {
+            return nil, fmt.Errorf("invalid allowlist pattern %q: %v", pat, err)
+        }
+        a.secrets = append(a.secrets, re)
+    }
+
+    if useBaseline && cfg.Baseline != "" {
+        baseline, err := readSecretsBaseline(cfg.Baseline)
+        if err != nil {
+            return nil, err
+        }
+        a.baseline = make(map[string]bool, len(baseline.Findings))
+        for _, entry := range baseline.Findings {
+            a.baseline[entry.Fingerprint] = true
+        }
+    }
+    return a, nil
+}
+
+// pathAllowed reports whether a whole file is exempt from scanning.
+func (a *secretAllowlist) pathAllowed(path string) bool {
+    for _, re := range a.paths {
+        if re.MatchString(path) {
+            return true
+        }
+    }
+    return false
+}
+
+// allowed reports whether a finding is acknowledged by a secret pattern or the baseline.
+func (a *secretAllowlist) allowed(f Finding) bool {
+    for _, re := range a.secrets {
+        if re.MatchString(f.Secret) {
+            return true
+        }
+    }
+    return a.baseline[f.Fingerprint]
+}
+
+// =======================
+// Baseline
+// =======================
+
+// baselineEntry is a known finding recorded in the baseline file.
+type baselineEntry struct {
+    RuleID      string `json:"ruleId"`
+    Path        string `json:"path"`
+    SecretHash  string `json:"secretHash"`
+    Fingerprint string `json:"fingerprint"`
+}
+
+// secretsBaseline is the content of the baseline file.
+type secretsBaseline struct {
+    Version  int             `json:"version"`
+    Findings []baselineEntry `json:"findings"`
+}
+
+// baselinePath resolves the baseline file against the repository root.
+func baselinePath(path string) (string, error) {
+    if filepath.IsAbs(path) {
+        return path, nil
+    }
+    root, err := gitRoot()
+    if err != nil {
+        return "", err
+    }
+    return filepath.Join(root, path), nil
+}
+
+// readSecretsBaseline loads the baseline file; a missing file is an empty baseline.
+func readSecretsBaseline(path string) (secretsBaseline, error) {
+    var baseline secretsBaseline
+    absPath, err := baselinePath(path)
+    if err != nil {
+        // Outside a repository there is no baseline to read.
+        return baseline, nil
+    }
+    data, err := os.ReadFile(absPath)
+    if os.IsNotExist(err) {
+        return baseline, nil
+    }
+    if err != nil {
+        return baseline, fmt.Errorf("failed to read secrets baseline %s: %v", absPath, err)
+    }
+    if err := json.Unmarshal(data, &baseline); err != nil {
+        return baseline, fmt.Errorf("failed to parse secrets baseline %s: %v", absPath, err)
+    }
+    return baseline, nil
+}
+
+// UpdateSecretsBaseline scans every tracked file and writes the findings to the baseline file,
+// replacing its previous content. Findings that are allowlisted or suppressed inline are left out.
+func UpdateSecretsBaseline() error {
+    cfg := loadConfigOrDefault().Secrets
+    if cfg.Baseline == "" {
+        return fmt.Errorf("no secrets baseline file configured (secrets.baseline)")
+    }
+    scanner, err := newSecretScanner(cfg, false)
+    if err != nil {
+        return err
+    }
+    findings, err := scanner.scanAll(context.Background(), trackedSource(scanner.filter))
+    if err != nil {
+        return err
+    }
+
+    old, err := readSecretsBaseline(cfg.Baseline)
+    if err != nil {
+        return err
+    }
+    known := make(map[string]bool, len(old.Findings))
+    for _, entry := range old.Findings {
+        known[entry.Fingerprint] = true
+    }
+
+    baseline := secretsBaseline{Version: 1, Findings: []baselineEntry{}}
+    seen := make(map[string]bool)
+    added := 0
+    for _, f := range findings {
+        if seen[f.Fingerprint] {
+            continue
+        }
+        seen[f.Fingerprint] = true
+        if !known[f.Fingerprint] {
+            added++
+        }
+        baseline.Findings = append(baseline.Findings, baselineEntry{
+            RuleID:      f.RuleID,
+            Path:        f.Path,
+            SecretHash:  secretHash(f.Secret),
+            Fingerprint: f.Fingerprint,
+        })
+    }
+    sort.Slice(baseline.Findings, func(i, j int) bool {
+        a, b := baseline.Findings[i], baseline.Findings[j]
+        if a.Path != b.Path {
+            return a.Path < b.Path
+        }
+        if a.RuleID != b.RuleID {
+            return a.RuleID < b.RuleID
+        }
+        return a.Fingerprint < b.Fingerprint
+    })
+
+    data, err := json.MarshalIndent(baseline, "", "  ")
+    if err != nil {
+        return fmt.Errorf("failed to encode secrets baseline: %v", err)
+    }
+    absPath, err := baselinePath(cfg.Baseline)
+    if err != nil {
+        return err
+    }
+    if err := os.WriteFile(absPath, append(data, '\n'), 0644); err != nil {
+        return fmt.Errorf("failed to write secrets baseline %s: %v", absPath, err)
+    }
+
+    removed := 0
+    for fp := range known {
+        if !seen[fp] {
+            removed++
+        }
+    }
+    fmt.Printf("Secrets baseline written to %s: %d findings (%d new, %d removed)\n",
+        absPath, len(baseline.Findings), added, removed)
+    return nil
+}
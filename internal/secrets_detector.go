@@ -0,0 +1,259 @@
+package internal
+
+import (
+    "bufio"
+    "bytes"
+    "context"
+    "encoding/json"
+    "fmt"
+    "os"
+    "os/exec"
+    "sort"
+    "strings"
+    "sync"
+    "time"
+)
+
+// Detector finds secrets in the content of one file. Findings report the 1-based line and column
+// in the content and the secret itself; the scanner fills in the file, fingerprint and snippet,
+// and applies inline suppressions, allowlists and the baseline. ctx is cancelled when the scan
+// stops early, on an interrupt or another file's error, and long-running detectors should honor it.
+type Detector interface {
+    Detect(ctx context.Context, path string, content []byte) ([]Finding, error)
+}
+
+// DetectorRule describes one rule ID a detector reports.
+type DetectorRule struct {
+    ID          string
+    Description string
+}
+
+// RuleLister is implemented by detectors that know their rule IDs up front, so that reports
+// such as SARIF can describe them. Rule IDs found in findings are described either way.
+type RuleLister interface {
+    Rules() []DetectorRule
+}
+
+// DetectorFactory creates a detector from the secrets config.
+type DetectorFactory func(cfg SecretsConfig) (Detector, error)
+
+// builtinDetector is the name of the built-in rule pack in the registry.
+const builtinDetector = "builtin"
+
+var (
+    detectorsMu      sync.Mutex
+    detectorRegistry = map[string]DetectorFactory{
+        builtinDetector: func(cfg SecretsConfig) (Detector, error) {
+            return newRuleDetector(secretRules, cfg.Entropy), nil
+        },
+    }
+)
+
+// RegisterDetector adds a detector that runs on every scan. Registering a name twice replaces the detector.
+func RegisterDetector(name string, factory DetectorFactory) {
+    detectorsMu.Lock()
+    defer detectorsMu.Unlock()
+    detectorRegistry[name] = factory
+}
+
+// namedDetector is a detector with the name used in error messages.
+type namedDetector struct {
+    name string
+    Detector
+}
+
+// newDetectors creates the registered detectors, built-in rules first, followed by the
+// external command detectors declared in the config.
+func newDetectors(cfg SecretsConfig) ([]namedDetector, error) {
+    detectorsMu.Lock()
+    names := make([]string, 0, len(detectorRegistry))
+    factories := make(map[string]DetectorFactory, len(detectorRegistry))
+    for name, factory := range detectorRegistry {
+        names = append(names, name)
+        factories[name] = factory
+    }
+    detectorsMu.Unlock()
+
+    sort.Slice(names, func(i, j int) bool {
+        if (names[i] == builtinDetector) != (names[j] == builtinDetector) {
+            return names[i] == builtinDetector
+        }
+        return names[i] < names[j]
+    })
+
+    var detectors []namedDetector
+    for _, name := range names {
+        d, err := factories[name](cfg)
+        if err != nil {
+            return nil, fmt.Errorf("detector %s: %v", name, err)
+        }
+        detectors = append(detectors, namedDetector{name, d})
+    }
+    for _, dc := range cfg.Detectors {
+        d, err := newCommandDetector(dc)
+        if err != nil {
+            return nil, err
+        }
+        detectors = append(detectors, namedDetector{dc.Name, d})
+    }
+    return detectors, nil
+}
+
+// =======================
+// Built-in Rules
+// =======================
+
+// ruleDetector runs regex rules with entropy thresholds, line by line.
+type ruleDetector struct {
+    rules    []secretRule
+    keywords *keywordMatcher
+    entropy  EntropyConfig
+}
+
+// newRuleDetector creates a detector for the rules with the configured entropy thresholds.
+func newRuleDetector(rules []secretRule, entropy EntropyConfig) *ruleDetector {
+    return &ruleDetector{rules: rules, keywords: newKeywordMatcher(rules), entropy: entropy}
+}
+
+// Rules lists the IDs and descriptions of the detector's rules.
+func (d *ruleDetector) Rules() []DetectorRule {
+    rules := make([]DetectorRule, len(d.rules))
+    for i, r := range d.rules {
+        rules[i] = DetectorRule{ID: r.id, Description: r.description}
+    }
+    return rules
+}
+
+// Detect runs the rules over the content. Each line only goes through the rules whose keywords it contains.
+func (d *ruleDetector) Detect(ctx context.Context, path string, content []byte) ([]Finding, error) {
+    hits := make([]bool, len(d.rules))
+    var findings []Finding
+    scanner := bufio.NewScanner(bytes.NewReader(content))
+    scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024) // Max 1MB line size
+
+    lineNo := 0
+    for scanner.Scan() {
+        lineNo++
+        line := scanner.Text()
+        d.keywords.candidates(strings.ToLower(line), hits)
+        var lineFindings []Finding
+        for i := range d.rules {
+            if !hits[i] {
+                continue
+            }
+            for _, f := range d.rules[i].matchLine(line, d.entropy) {
+                // Specific rules come first; skip generic matches of the same secret.
+                if overlapsAny(f, lineFindings) {
+                    continue
+                }
+                f.Line = lineNo
+                lineFindings = append(lineFindings, f)
+            }
+        }
+        findings = append(findings, lineFindings...)
+    }
+    return findings, scanner.Err()
+}
+
+// overlapsAny reports whether a finding's secret overlaps one already found on the same line.
+func overlapsAny(f Finding, found []Finding) bool {
+    for _, other := range found {
+        if f.Column < other.Column+len(other.Secret) && other.Column < f.Column+len(f.Secret) {
+            return true
+        }
+    }
+    return false
+}
+
+// =======================
+// External Commands
+// =======================
+
+// defaultDetectorTimeout bounds an external detector run when the config sets no timeout.
+const defaultDetectorTimeout = 30 * time.Second
+
+// commandDetector runs an external command that receives the content on stdin and prints
+// a JSON array of findings.
+type commandDetector struct {
+    name    string
+    command []string
+    timeout time.Duration
+}
+
+// newCommandDetector validates a detector declared in the config.
+func newCommandDetector(cfg CommandDetectorConfig) (*commandDetector, error) {
+    if cfg.Name == "" {
+        return nil, fmt.Errorf("secrets.detectors: detector without a name")
+    }
+    if len(cfg.Command) == 0 {
+        return nil, fmt.Errorf("secrets.detectors: detector %s has no command", cfg.Name)
+    }
+    timeout := defaultDetectorTimeout
+    if cfg.Timeout > 0 {
+        timeout = time.Duration(cfg.Timeout) * time.Second
+    }
+    return &commandDetector{name: cfg.Name, command: cfg.Command, timeout: timeout}, nil
+}
+
+// commandFinding is a finding as printed by an external detector.
+type commandFinding struct {
+    RuleID      string `json:"ruleId"`
+    Description string `json:"description"`
+    Line        int    `json:"line"`
+    Column      int    `json:"column"`
+    Secret      string `json:"secret"`
+}
+
+// Rules lists the detector name, the rule ID of findings that do not set one.
+func (d *commandDetector) Rules() []DetectorRule {
+    return []DetectorRule{{ID: d.name, Description: "Secret reported by the " + d.name + " detector"}}
+}
+
+// Detect runs the command with the content on stdin and the file path in GOMMITIZEN_PATH.
+// Exit status 0 and 1 both mean success, so tools that fail when they find something work as is.
+// The command is killed when the scan is cancelled or the timeout expires.
+func (d *commandDetector) Detect(ctx context.Context, path string, content []byte) ([]Finding, error) {
+    ctx, cancel := context.WithTimeout(ctx, d.timeout)
+    defer cancel()
+
+    cmd := exec.CommandContext(ctx, d.command[0], d.command[1:]...)
+    cmd.Env = append(os.Environ(), "GOMMITIZEN_PATH="+path)
+    cmd.Stdin = bytes.NewReader(content)
+    var stdout, stderr bytes.Buffer
+    cmd.Stdout = &stdout
+    cmd.Stderr = &stderr
+    if err := cmd.Run(); err != nil {
+        exitErr, ok := err.(*exec.ExitError)
+        if ctx.Err() != nil || !ok || exitErr.ExitCode() != 1 {
+            if msg := strings.TrimSpace(stderr.String()); msg != "" {
+                return nil, fmt.Errorf("%v: %s", err, msg)
+            }
+            return nil, err
+        }
+    }
+
+    out := bytes.TrimSpace(stdout.Bytes())
+    if len(out) == 0 {
+        return nil, nil
+    }
+    var found []commandFinding
+    if err := json.Unmarshal(out, &found); err != nil {
+        return nil, fmt.Errorf("invalid output, expected a JSON array of findings: %v", err)
+    }
+
+    findings := make([]Finding, 0, len(found))
+    for _, f := range found {
+        ruleID := f.RuleID
+        if ruleID == "" {
+            ruleID = d.name
+        }
+        findings = append(findings, Finding{
+            RuleID:      ruleID,
+            Description: f.Description,
+            Line:        f.Line,
+            Column:      f.Column,
+            Secret:      f.Secret,
+        })
+    }
+    return findings, nil
+}
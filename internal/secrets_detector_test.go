@@ -0,0 +1,79 @@
+package internal
+
+import (
+    "context"
+    "reflect"
+    "testing"
+    "time"
+)
+
+func TestCommandDetector(t *testing.T) {
+    tests := []struct {
+        name    string
+        script  string
+        want    []Finding
+        wantErr bool
+    }{
+        {
+            name:   "findings",
+            script: `cat >/dev/null; echo '[{"ruleId": "acme", "line": 2, "column": 3, "secret": "s3cr3t"}, {"line": 1, "column": 1, "secret": "x"}]'`,
+            want: []Finding{
+                {RuleID: "acme", Line: 2, Column: 3, Secret: "s3cr3t"},
+                {RuleID: "custom", Line: 1, Column: 1, Secret: "x"},
+            },
+        },
+        {name: "exit status 1 with findings", script: `echo '[{"line": 1, "column": 1, "secret": "x"}]'; exit 1`,
+            want: []Finding{{RuleID: "custom", Line: 1, Column: 1, Secret: "x"}}},
+        {name: "no output", script: `exit 0`},
+        {name: "path in the environment", script: `test "$GOMMITIZEN_PATH" = "a/b.env" || exit 2`},
+        {name: "failure", script: `echo boom >&2; exit 2`, wantErr: true},
+        {name: "invalid output", script: `echo 'not json'`, wantErr: true},
+    }
+    for _, tt := range tests {
+        t.Run(tt.name, func(t *testing.T) {
+            d, err := newCommandDetector(CommandDetectorConfig{Name: "custom", Command: []string{"sh", "-c", tt.script}})
+            if err != nil {
+                t.Fatal(err)
+            }
+            got, err := d.Detect(context.Background(), "a/b.env", []byte("content\n"))
+            if (err != nil) != tt.wantErr {
+                t.Fatalf("error = %v, want error %v", err, tt.wantErr)
+            }
+            if len(got) == 0 && len(tt.want) == 0 {
+                return
+            }
+            if !reflect.DeepEqual(got, tt.want) {
+                t.Errorf("got %+v, want %+v", got, tt.want)
+            }
+        })
+    }
+}
+
+func TestCommandDetectorCancel(t *testing.T) {
+    d, err := newCommandDetector(CommandDetectorConfig{Name: "slow", Command: []string{"sleep", "10"}})
+    if err != nil {
+        t.Fatal(err)
+    }
+    ctx, cancel := context.WithCancel(context.Background())
+    time.AfterFunc(50*time.Millisecond, cancel)
+
+    start := time.Now()
+    if _, err := d.Detect(ctx, "a.txt", nil); err == nil {
+        t.Error("cancelled detector succeeded")
+    }
+    if elapsed := time.Since(start); elapsed > 5*time.Second {
+        t.Errorf("detector ran for %v after the scan was cancelled", elapsed)
+    }
+}
+
+func TestNewCommandDetector(t *testing.T) {
+    for _, cfg := range []CommandDetectorConfig{{Command: []string{"true"}}, {Name: "empty"}} {
+        if _, err := newCommandDetector(cfg); err == nil {
+            t.Errorf("newCommandDetector(%+v) succeeded, want an error", cfg)
+        }
+    }
+    d, err := newCommandDetector(CommandDetectorConfig{Name: "x", Command: []string{"true"}, Timeout: 5})
+    if err != nil || d.timeout != 5*time.Second {
+        t.Errorf("timeout = %v, %v", d.timeout, err)
+    }
+}
@@ -0,0 +1,195 @@
+package internal
+
+import (
+    "bytes"
+    "fmt"
+    "path/filepath"
+    "regexp"
+    "strings"
+)
+
+// =======================
+// Gitignore-style Patterns
+// =======================
+
+// globPattern is one compiled gitignore-style pattern.
+type globPattern struct {
+    negate bool
+    regex  *regexp.Regexp
+}
+
+// pathMatcher matches paths against a list of gitignore-style patterns; the last matching pattern wins,
+// so "!pattern" re-includes paths matched by an earlier pattern.
+type pathMatcher struct {
+    patterns []globPattern
+}
+
+// newPathMatcher compiles gitignore-style patterns. Blank patterns and "#" comments are ignored.
+func newPathMatcher(patterns []string) (*pathMatcher, error) {
+    m := &pathMatcher{}
+    for _, pat := range patterns {
+        pat = strings.TrimSpace(pat)
+        if pat == "" || strings.HasPrefix(pat, "#") {
+            continue
+        }
+        negate := strings.HasPrefix(pat, "!")
+        re, err := globRegexp(strings.TrimPrefix(pat, "!"))
+        if err != nil {
+            return nil, fmt.Errorf("invalid pattern %q: %v", pat, err)
+        }
+        m.patterns = append(m.patterns, globPattern{negate: negate, regex: re})
+    }
+    return m, nil
+}
+
+// empty reports whether the matcher has no patterns.
+func (m *pathMatcher) empty() bool {
+    return len(m.patterns) == 0
+}
+
+// match reports whether a slash-separated path, relative to the repository root, is matched.
+func (m *pathMatcher) match(path string) bool {
+    matched := false
+    for _, p := range m.patterns {
+        if p.regex.MatchString(path) {
+            matched = !p.negate
+        }
+    }
+    return matched
+}
+
+// globRegexp translates a gitignore-style pattern into a regexp matching file paths:
+//   - a pattern without a slash (other than a trailing one) matches at any depth;
+//   - a trailing slash matches directories only, that is every file below them;
+//   - "*" and "?" do not cross "/", "**" does, and a pattern matching a directory
+//     also matches everything below it.
+func globRegexp(pattern string) (*regexp.Regexp, error) {
+    dirOnly := strings.HasSuffix(pattern, "/")
+    pattern = strings.TrimSuffix(pattern, "/")
+    anchored := strings.Contains(pattern, "/")
+    pattern = strings.TrimPrefix(pattern, "/")
+    if pattern == "" {
+        return nil, fmt.Errorf("empty pattern")
+    }
+
+    var b strings.Builder
+    b.WriteString("^")
+    if !anchored {
+        b.WriteString("(?:.*/)?")
+    }
+    for i := 0; i < len(pattern); i++ {
+        c := pattern[i]
+        switch c {
+        case '*':
+            if i+1 < len(pattern) && pattern[i+1] == '*' {
+                i++
+                if i+1 < len(pattern) && pattern[i+1] == '/' {
+                    // "**/" matches zero or more directories.
+                    i++
+                    b.WriteString("(?:.*/)?")
+                } else {
+                    b.WriteString(".*")
+                }
+            } else {
+                b.WriteString("[^/]*")
+            }
+        case '?':
+            b.WriteString("[^/]")
+        case '[':
+            end := strings.IndexByte(pattern[i+1:], ']')
+            if end < 0 {
+                b.WriteString(`\[`)
+                continue
+            }
+            class := pattern[i+1 : i+1+end]
+            if strings.HasPrefix(class, "!") {
+                class = "^" + class[1:]
+            }
+            b.WriteString("[" + class + "]")
+            i += end + 1
+        case '\\':
+            if i+1 < len(pattern) {
+                i++
+                b.WriteString(regexp.QuoteMeta(string(pattern[i])))
+            }
+        default:
+            b.WriteString(regexp.QuoteMeta(string(c)))
+        }
+    }
+    if dirOnly {
+        b.WriteString("/.*$")
+    } else {
+        b.WriteString("(?:/.*)?$")
+    }
+    return regexp.Compile(b.String())
+}
+
+// =======================
+// Scan Filter
+// =======================
+
+// binarySniffLength is how much of a file is checked for NUL bytes, as git does.
+const binarySniffLength = 8000
+
+// lfsPointerPrefix starts every Git LFS pointer file.
+var lfsPointerPrefix = []byte("version https://git-lfs.github.com/spec/v1")
+
+// scanFilter decides which files the secret scanner reads.
+type scanFilter struct {
+    include     *pathMatcher
+    exclude     *pathMatcher
+    maxFileSize int64
+    baseline    string // The baseline file, whose hashes would otherwise look like secrets
+}
+
+// newScanFilter compiles the include and exclude patterns of the config.
+func newScanFilter(cfg SecretsConfig) (*scanFilter, error) {
+    include, err := newPathMatcher(cfg.Include)
+    if err != nil {
+        return nil, fmt.Errorf("secrets.include: %v", err)
+    }
+    exclude, err := newPathMatcher(cfg.Exclude)
+    if err != nil {
+        return nil, fmt.Errorf("secrets.exclude: %v", err)
+    }
+    return &scanFilter{
+        include:     include,
+        exclude:     exclude,
+        maxFileSize: cfg.MaxFileSize,
+        baseline:    filepath.ToSlash(filepath.Clean(cfg.Baseline)),
+    }, nil
+}
+
+// skipPath reports whether a file is the baseline file or is left out by the include and exclude patterns.
+func (f *scanFilter) skipPath(path string) bool {
+    if path == f.baseline {
+        return true
+    }
+    if !f.include.empty() && !f.include.match(path) {
+        return true
+    }
+    return f.exclude.match(path)
+}
+
+// skipDir reports whether every file below a directory is excluded, so it need not be walked.
+func (f *scanFilter) skipDir(dir string) bool {
+    return f.exclude.match(dir + "/")
+}
+
+// tooLarge reports whether content of the given size exceeds the configured maximum.
+func (f *scanFilter) tooLarge(size int64) bool {
+    return f.maxFileSize > 0 && size > f.maxFileSize
+}
+
+// skipContent reports whether content is too large, binary or a Git LFS pointer.
+// LFS pointers only hold the object ID; the real content is not in the repository.
+func (f *scanFilter) skipContent(content []byte) bool {
+    if f.tooLarge(int64(len(content))) {
+        return true
+    }
+    sniff := content
+    if len(sniff) > binarySniffLength {
+        sniff = sniff[:binarySniffLength]
+    }
+    return bytes.IndexByte(sniff, 0) != -1 || bytes.HasPrefix(content, lfsPointerPrefix)
+}
@@ -0,0 +1,109 @@
+package internal
+
+import "testing"
+
+func TestGlobRegexp(t *testing.T) {
+    tests := []struct {
+        pattern string
+        path    string
+        want    bool
+    }{
+        {"*.lock", "yarn.lock", true},
+        {"*.lock", "web/yarn.lock", true},
+        {"*.lock", "yarn.locked", false},
+        {"vendor/", "vendor/lib/a.go", true},
+        {"vendor/", "src/vendor/a.go", true},
+        {"vendor/", "vendor", false},
+        {"/testdata/", "testdata/key.pem", true},
+        {"/testdata/", "pkg/testdata/key.pem", false},
+        {"docs/*.md", "docs/a.md", true},
+        {"docs/*.md", "docs/sub/a.md", false},
+        {"docs/**/*.md", "docs/a.md", true},
+        {"docs/**/*.md", "docs/sub/deep/a.md", true},
+        {"**/fixtures", "a/b/fixtures/key.txt", true},
+        {"config", "config/app.yml", true},
+        {"?.env", "a.env", true},
+        {"?.env", "ab.env", false},
+        {"key[0-9].pem", "key1.pem", true},
+        {"key[!0-9].pem", "key1.pem", false},
+        {"key[!0-9].pem", "keyx.pem", true},
+        {`\*.txt`, "*.txt", true},
+        {`\*.txt`, "a.txt", false},
+        {"a.b", "axb", false},
+    }
+    for _, tt := range tests {
+        t.Run(tt.pattern+" "+tt.path, func(t *testing.T) {
+            re, err := globRegexp(tt.pattern)
+            if err != nil {
+                t.Fatalf("globRegexp(%q): %v", tt.pattern, err)
+            }
+            if got := re.MatchString(tt.path); got != tt.want {
+                t.Errorf("globRegexp(%q) matches %q = %v, want %v (regexp %s)", tt.pattern, tt.path, got, tt.want, re)
+            }
+        })
+    }
+
+    for _, pattern := range []string{"", "/"} {
+        if _, err := globRegexp(pattern); err == nil {
+            t.Errorf("globRegexp(%q) succeeded, want an error", pattern)
+        }
+    }
+}
+
+func TestScanFilter(t *testing.T) {
+    f, err := newScanFilter(SecretsConfig{
+        Include:     []string{"src/", "*.env"},
+        Exclude:     []string{"vendor/", "*.lock", "!Cargo.lock", "# comment", ""},
+        MaxFileSize: 16,
+        Baseline:    ".gommitizen-secrets.json",
+    })
+    if err != nil {
+        t.Fatal(err)
+    }
+
+    paths := []struct {
+        path string
+        skip bool
+    }{
+        {"src/main.go", false},
+        {"prod.env", false},
+        {"docs/readme.md", true},
+        {"src/vendor/lib.go", true},
+        {"src/yarn.lock", true},
+        {"src/Cargo.lock", false},
+        {".gommitizen-secrets.json", true},
+    }
+    for _, tt := range paths {
+        if got := f.skipPath(tt.path); got != tt.skip {
+            t.Errorf("skipPath(%q) = %v, want %v", tt.path, got, tt.skip)
+        }
+    }
+
+    contents := []struct {
+        name    string
+        content string
+        skip    bool
+    }{
+        {"text", "token=abc\n", false},
+        {"empty", "", false},
+        {"too large", "0123456789abcdefg", true},
+        {"binary", "ab\x00cd", true},
+    }
+    for _, tt := range contents {
+        if got := f.skipContent([]byte(tt.content)); got != tt.skip {
+            t.Errorf("skipContent(%s) = %v, want %v", tt.name, got, tt.skip)
+        }
+    }
+
+    unlimited, _ := newScanFilter(SecretsConfig{})
+    if !unlimited.skipContent([]byte("version https://git-lfs.github.com/spec/v1\noid sha256:abc\n")) {
+        t.Error("skipContent did not skip a Git LFS pointer")
+    }
+    if unlimited.tooLarge(1 << 40) {
+        t.Error("tooLarge with no limit reported a file as too large")
+    }
+
+    if _, err := newScanFilter(SecretsConfig{Exclude: []string{"/"}}); err == nil {
+        t.Error("newScanFilter accepted an empty pattern")
+    }
+}
@@ -0,0 +1,84 @@
+package internal
+
+// keywordMatcher finds which rules' keywords occur in a line with a single pass over it,
+// using an Aho-Corasick automaton built from the lower-case keywords of every rule.
+// Only the rules whose keywords were found run their regexes.
+type keywordMatcher struct {
+    next    [][256]int32 // Goto function completed with failure transitions, so matching never backtracks
+    outputs [][]int      // Rule indexes whose keyword ends at each state, including shorter suffixes
+    always  []int        // Rules without keywords, which run on every line
+    rules   int
+}
+
+// newKeywordMatcher builds the automaton for the rules' keywords.
+func newKeywordMatcher(rules []secretRule) *keywordMatcher {
+    m := &keywordMatcher{rules: len(rules)}
+    m.addState()
+
+    for i, r := range rules {
+        if len(r.keywords) == 0 {
+            m.always = append(m.always, i)
+            continue
+        }
+        for _, kw := range r.keywords {
+            state := int32(0)
+            for j := 0; j < len(kw); j++ {
+                c := kw[j]
+                if m.next[state][c] == 0 {
+                    m.next[state][c] = int32(m.addState())
+                }
+                state = m.next[state][c]
+            }
+            m.outputs[state] = append(m.outputs[state], i)
+        }
+    }
+
+    // Breadth-first, point missing transitions at the failure state's transitions
+    // and merge the outputs of failure states.
+    fail := make([]int32, len(m.next))
+    var queue []int32
+    for c := 0; c < 256; c++ {
+        if s := m.next[0][c]; s != 0 {
+            queue = append(queue, s)
+        }
+    }
+    for len(queue) > 0 {
+        state := queue[0]
+        queue = queue[1:]
+        m.outputs[state] = append(m.outputs[state], m.outputs[fail[state]]...)
+        for c := 0; c < 256; c++ {
+            s := m.next[state][c]
+            if s == 0 {
+                m.next[state][c] = m.next[fail[state]][c]
+                continue
+            }
+            fail[s] = m.next[fail[state]][c]
+            queue = append(queue, s)
+        }
+    }
+    return m
+}
+
+// addState appends an empty state and returns its index.
+func (m *keywordMatcher) addState() int {
+    m.next = append(m.next, [256]int32{})
+    m.outputs = append(m.outputs, nil)
+    return len(m.next) - 1
+}
+
+// candidates marks in hits the rules that may match a lower-case line. hits must have one entry per rule.
+func (m *keywordMatcher) candidates(lowerLine string, hits []bool) {
+    for i := range hits {
+        hits[i] = false
+    }
+    for _, i := range m.always {
+        hits[i] = true
+    }
+    state := int32(0)
+    for j := 0; j < len(lowerLine); j++ {
+        state = m.next[state][lowerLine[j]]
+        for _, i := range m.outputs[state] {
+            hits[i] = true
+        }
+    }
+}
@@ -0,0 +1,55 @@
+package internal
+
+import (
+    "reflect"
+    "testing"
+)
+
+func TestKeywordMatcher(t *testing.T) {
+    rules := []secretRule{
+        {id: "he", keywords: []string{"he"}},
+        {id: "she-his", keywords: []string{"she", "his"}},
+        {id: "hers", keywords: []string{"hers"}},
+        {id: "always"},
+    }
+    m := newKeywordMatcher(rules)
+
+    tests := []struct {
+        line string
+        want []string
+    }{
+        {"", []string{"always"}},
+        {"nothing here", []string{"he", "always"}},
+        {"ushers", []string{"he", "she-his", "hers", "always"}},
+        {"this", []string{"she-his", "always"}},
+        {"hhe", []string{"he", "always"}},
+        {"h e r s", []string{"always"}},
+    }
+    for _, tt := range tests {
+        t.Run(tt.line, func(t *testing.T) {
+            hits := make([]bool, len(rules))
+            m.candidates(tt.line, hits)
+            var got []string
+            for i, hit := range hits {
+                if hit {
+                    got = append(got, rules[i].id)
+                }
+            }
+            if !reflect.DeepEqual(got, tt.want) {
+                t.Errorf("candidates(%q) = %v, want %v", tt.line, got, tt.want)
+            }
+        })
+    }
+}
+
+func TestKeywordMatcherBuiltinRules(t *testing.T) {
+    m := newKeywordMatcher(secretRules)
+    hits := make([]bool, len(secretRules))
+    m.candidates("export github_token=ghp_abc", hits)
+    want := map[string]bool{"github-token": true, "generic-secret-assignment": true}
+    for i, r := range secretRules {
+        if hits[i] != want[r.id] {
+            t.Errorf("rule %s: hit = %v, want %v", r.id, hits[i], want[r.id])
+        }
+    }
+}
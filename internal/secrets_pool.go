@@ -0,0 +1,104 @@
+package internal
+
+import (
+    "context"
+    "fmt"
+    "runtime"
+    "sort"
+    "sync"
+)
+
+// scanAll scans every target of the source with a pool of GOMAXPROCS workers and returns
+// all findings in the order the targets were produced. Targets are handed to the workers
+// as they are produced, and lazily loaded content is only read by the worker scanning it.
+// The scan stops at the first error or when ctx is cancelled.
+func (s *secretScanner) scanAll(ctx context.Context, source targetSource) ([]Finding, error) {
+    ctx, cancel := context.WithCancel(ctx)
+    defer cancel()
+
+    type job struct {
+        index  int
+        target scanTarget
+    }
+    type result struct {
+        index    int
+        findings []Finding
+    }
+
+    workers := runtime.GOMAXPROCS(0)
+    jobs := make(chan job, workers*4)
+    results := make(chan result, workers*4)
+
+    var scanErr error
+    var errOnce sync.Once
+    fail := func(err error) {
+        errOnce.Do(func() {
+            scanErr = err
+            cancel()
+        })
+    }
+
+    var wg sync.WaitGroup
+    for i := 0; i < workers; i++ {
+        wg.Add(1)
+        go func() {
+            defer wg.Done()
+            for j := range jobs {
+                if ctx.Err() != nil {
+                    continue
+                }
+                findings, err := s.scan(ctx, j.target)
+                if err != nil {
+                    fail(fmt.Errorf("failed to scan %s: %v", j.target.path, err))
+                    continue
+                }
+                if len(findings) > 0 {
+                    results <- result{j.index, findings}
+                }
+            }
+        }()
+    }
+
+    // Collect results while the workers run so they never block on a full channel.
+    var reported []result
+    collected := make(chan struct{})
+    go func() {
+        for r := range results {
+            reported = append(reported, r)
+        }
+        close(collected)
+    }()
+
+    count := 0
+    sourceErr := source(func(target scanTarget) error {
+        select {
+        case jobs <- job{count, target}:
+            count++
+            return nil
+        case <-ctx.Done():
+            return ctx.Err()
+        }
+    })
+    close(jobs)
+    wg.Wait()
+    close(results)
+    <-collected
+
+    // A worker error cancels the source, so report it rather than the cancellation.
+    if scanErr != nil {
+        return nil, scanErr
+    }
+    if sourceErr != nil {
+        return nil, sourceErr
+    }
+    if err := ctx.Err(); err != nil {
+        return nil, err
+    }
+
+    sort.Slice(reported, func(i, j int) bool { return reported[i].index < reported[j].index })
+    var findings []Finding
+    for _, r := range reported {
+        findings = append(findings, r.findings...)
+    }
+    return findings, nil
+}
@@ -0,0 +1,207 @@
+package internal
+
+import (
+    "context"
+    "encoding/json"
+    "fmt"
+    "io"
+
+    "gommitizen/internal/utils"
+)
+
+// ScanOptions selects what the scan command reads and how it reports findings.
+// Without a range, history or paths, the changes about to be committed are scanned.
+type ScanOptions struct {
+    FullFile   bool     // When scanning staged changes, scan whole files instead of only the added lines
+    Range      string   // Scan the lines added by the commits in a revision range, e.g. "main..HEAD"
+    AllHistory bool     // Scan the lines added by every commit reachable from any ref
+    Paths      []string // Scan these working-tree files and directories
+    Format     string   // Output format: "text", "json" or "sarif"
+    NoBaseline bool     // Report findings recorded in the baseline file too
+}
+
+// ScanSecrets scans the selected content for secrets and writes the findings to out.
+// Findings are listed in the order they were found: by file, then line, and for history scans
+// newest commit first. It returns the number of findings reported.
+func ScanSecrets(ctx context.Context, opts ScanOptions, out io.Writer) (int, error) {
+    format := opts.Format
+    switch format {
+    case "":
+        format = "text"
+    case "text", "json", "sarif":
+    default:
+        return 0, fmt.Errorf("unknown output format %q (expected text, json or sarif)", format)
+    }
+
+    cfg := loadConfigOrDefault().Secrets
+    scanner, err := newSecretScanner(cfg, !opts.NoBaseline)
+    if err != nil {
+        return 0, err
+    }
+
+    var source targetSource
+    switch {
+    case opts.Range != "":
+        source = historySource([]string{opts.Range})
+    case opts.AllHistory:
+        source = historySource([]string{"--all"})
+    case len(opts.Paths) > 0:
+        source = pathSource(opts.Paths, scanner.filter)
+    default:
+        source = stagedSource(opts.FullFile, false)
+    }
+    findings, err := scanner.scanAll(ctx, source)
+    if err != nil {
+        return 0, err
+    }
+
+    switch format {
+    case "json":
+        err = writeFindingsJSON(out, findings)
+    case "sarif":
+        err = writeFindingsSARIF(out, scanner.rules(), findings)
+    default:
+        writeFindingsText(out, findings)
+    }
+    return len(findings), err
+}
+
+// writeFindingsText prints one compiler-style line per finding.
+func writeFindingsText(out io.Writer, findings []Finding) {
+    for _, f := range findings {
+        fmt.Fprintln(out, f.String())
+    }
+    switch len(findings) {
+    case 0:
+    case 1:
+        fmt.Fprintln(out, utils.Color("1 secret found.", "red"))
+    default:
+        fmt.Fprintln(out, utils.Color(fmt.Sprintf("%d secrets found.", len(findings)), "red"))
+    }
+}
+
+// writeFindingsJSON writes the findings as a JSON document.
+func writeFindingsJSON(out io.Writer, findings []Finding) error {
+    if findings == nil {
+        findings = []Finding{}
+    }
+    enc := json.NewEncoder(out)
+    enc.SetIndent("", "  ")
+    return enc.Encode(struct {
+        Findings []Finding `json:"findings"`
+    }{findings})
+}
+
+// =======================
+// SARIF
+// =======================
+
+// The subset of SARIF 2.1.0 understood by code scanning tools.
+type sarifLog struct {
+    Schema  string     `json:"$schema"`
+    Version string     `json:"version"`
+    Runs    []sarifRun `json:"runs"`
+}
+
+type sarifRun struct {
+    Tool    sarifTool     `json:"tool"`
+    Results []sarifResult `json:"results"`
+}
+
+type sarifTool struct {
+    Driver sarifDriver `json:"driver"`
+}
+
+type sarifDriver struct {
+    Name           string      `json:"name"`
+    InformationURI string      `json:"informationUri"`
+    Rules          []sarifRule `json:"rules"`
+}
+
+type sarifRule struct {
+    ID               string       `json:"id"`
+    ShortDescription sarifMessage `json:"shortDescription"`
+}
+
+type sarifMessage struct {
+    Text string `json:"text"`
+}
+
+type sarifResult struct {
+    RuleID              string            `json:"ruleId"`
+    Level               string            `json:"level"`
+    Message             sarifMessage      `json:"message"`
+    Locations           []sarifLocation   `json:"locations"`
+    PartialFingerprints map[string]string `json:"partialFingerprints"`
+    Properties          map[string]string `json:"properties,omitempty"`
+}
+
+type sarifLocation struct {
+    PhysicalLocation sarifPhysicalLocation `json:"physicalLocation"`
+}
+
+type sarifPhysicalLocation struct {
+    ArtifactLocation sarifArtifactLocation `json:"artifactLocation"`
+    Region           sarifRegion           `json:"region"`
+}
+
+type sarifArtifactLocation struct {
+    URI string `json:"uri"`
+}
+
+type sarifRegion struct {
+    StartLine   int `json:"startLine"`
+    StartColumn int `json:"startColumn"`
+}
+
+// writeFindingsSARIF writes the findings as a SARIF 2.1.0 log. The driver lists the rules of
+// every detector, and any other rule ID a finding refers to, so that every result has its rule.
+func writeFindingsSARIF(out io.Writer, rules []DetectorRule, findings []Finding) error {
+    driver := sarifDriver{
+        Name:           "gommitizen",
+        InformationURI: "https://github.com/tiendu/gommitizen",
+    }
+    listed := make(map[string]bool)
+    addRule := func(id, description string) {
+        if listed[id] {
+            return
+        }
+        listed[id] = true
+        if description == "" {
+            description = id
+        }
+        driver.Rules = append(driver.Rules, sarifRule{ID: id, ShortDescription: sarifMessage{description}})
+    }
+    for _, r := range rules {
+        addRule(r.ID, r.Description)
+    }
+    for _, f := range findings {
+        addRule(f.RuleID, f.Description)
+    }
+
+    results := []sarifResult{}
+    for _, f := range findings {
+        result := sarifResult{
+            RuleID:  f.RuleID,
+            Level:   "error",
+            Message: sarifMessage{fmt.Sprintf("%s detected: %s", f.Description, f.Snippet)},
+            Locations: []sarifLocation{{PhysicalLocation: sarifPhysicalLocation{
+                ArtifactLocation: sarifArtifactLocation{URI: f.Path},
+                Region:           sarifRegion{StartLine: f.Line, StartColumn: f.Column},
+            }}},
+            PartialFingerprints: map[string]string{"gommitizen/v1": f.Fingerprint},
+        }
+        if f.Commit != "" {
+            result.Properties = map[string]string{"commit": f.Commit}
+        }
+        results = append(results, result)
+    }
+
+    enc := json.NewEncoder(out)
+    enc.SetIndent("", "  ")
+    return enc.Encode(sarifLog{
+        Schema:  "https://json.schemastore.org/sarif-2.1.0.json",
+        Version: "2.1.0",
+        Runs:    []sarifRun{{Tool: sarifTool{Driver: driver}, Results: results}},
+    })
+}
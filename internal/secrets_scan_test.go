@@ -0,0 +1,51 @@
+package internal
+
+import (
+    "bytes"
+    "encoding/json"
+    "testing"
+)
+
+func TestWriteFindingsSARIF(t *testing.T) {
+    rules := append(newRuleDetector(secretRules, EntropyConfig{}).Rules(),
+        DetectorRule{ID: "acme", Description: "Secret reported by the acme detector"})
+    findings := []Finding{
+        {RuleID: "github-token", Description: "GitHub token", Path: "a.go", Line: 3, Column: 5, Snippet: "ghp_***", Fingerprint: "f1"},
+        {RuleID: "acme", Path: "b.env", Line: 1, Column: 1, Snippet: "***", Fingerprint: "f2"},
+        {RuleID: "acme-token", Description: "ACME API token", Path: "c.env", Line: 2, Column: 1, Snippet: "***", Fingerprint: "f3", Commit: "abc"},
+    }
+
+    var buf bytes.Buffer
+    if err := writeFindingsSARIF(&buf, rules, findings); err != nil {
+        t.Fatal(err)
+    }
+    var log sarifLog
+    if err := json.Unmarshal(buf.Bytes(), &log); err != nil {
+        t.Fatalf("invalid SARIF: %v", err)
+    }
+    run := log.Runs[0]
+
+    described := make(map[string]string)
+    for _, r := range run.Tool.Driver.Rules {
+        if _, dup := described[r.ID]; dup {
+            t.Errorf("rule %s listed twice", r.ID)
+        }
+        described[r.ID] = r.ShortDescription.Text
+    }
+    for _, r := range secretRules {
+        if described[r.id] != r.description {
+            t.Errorf("built-in rule %s = %q, want %q", r.id, described[r.id], r.description)
+        }
+    }
+    if described["acme-token"] != "ACME API token" {
+        t.Errorf("rule only found in a finding = %q", described["acme-token"])
+    }
+    for _, res := range run.Results {
+        if _, ok := described[res.RuleID]; !ok {
+            t.Errorf("result refers to unlisted rule %s", res.RuleID)
+        }
+    }
+    if len(run.Results) != 3 || run.Results[2].Properties["commit"] != "abc" || run.Results[0].PartialFingerprints["gommitizen/v1"] != "f1" {
+        t.Errorf("results = %+v", run.Results)
+    }
+}
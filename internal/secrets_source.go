@@ -0,0 +1,436 @@
+package internal
+
+import (
+    "bufio"
+    "bytes"
+    "fmt"
+    "io"
+    "io/fs"
+    "os"
+    "os/exec"
+    "path/filepath"
+    "strconv"
+    "strings"
+)
+
+// scanTarget is a piece of content to scan for secrets.
+type scanTarget struct {
+    path    string
+    commit  string                 // Commit that introduced the content, for history scans
+    content []byte
+    load    func() ([]byte, error) // Reads the content when it is nil, so files are only opened by the worker scanning them
+    size    func() (int64, error)  // Size of the content to load, checked first so oversized files are never read; nil when unknown
+    lines   []int                  // File line number of each content line; nil when content is the whole file
+}
+
+// targetSource produces scan targets, passing each one to emit and stopping at the first error emit returns.
+type targetSource func(emit func(scanTarget) error) error
+
+// sliceSource emits targets that are already known.
+func sliceSource(targets []scanTarget) targetSource {
+    return func(emit func(scanTarget) error) error {
+        for _, t := range targets {
+            if err := emit(t); err != nil {
+                return err
+            }
+        }
+        return nil
+    }
+}
+
+// lineNumber maps a 1-based line of the target content to the line in the file.
+func (t scanTarget) lineNumber(n int) int {
+    if t.lines == nil || n < 1 || n > len(t.lines) {
+        return n
+    }
+    return t.lines[n-1]
+}
+
+// emptyTree is the ID of the tree with no files, which every repository has.
+const emptyTree = "4b825dc642cb6eb9a060e54bf8d69288fbee4904"
+
+// stagedDiffBase returns the "git diff" arguments selecting the changes about to be committed.
+// With workingTree, tracked working-tree changes are included, as "git commit -a" will stage them.
+// Before the first commit they are compared with the empty tree, since HEAD does not exist yet.
+func stagedDiffBase(workingTree bool) []string {
+    if workingTree {
+        if err := exec.Command("git", "rev-parse", "--verify", "--quiet", "HEAD").Run(); err != nil {
+            return []string{"diff", emptyTree}
+        }
+        return []string{"diff", "HEAD"}
+    }
+    return []string{"diff", "--cached"}
+}
+
+// stagedFiles lists the files added, copied, modified or renamed in the changes about to be committed.
+func stagedFiles(workingTree bool) ([]string, error) {
+    args := append(stagedDiffBase(workingTree), "--name-only", "-z", "--diff-filter=ACMR")
+    cmd := exec.Command("git", args...)
+    output, err := cmd.Output()
+    if err != nil {
+        return nil, fmt.Errorf("failed to get staged files: %v", err)
+    }
+    var files []string
+    for _, name := range strings.Split(string(output), "\x00") {
+        if name != "" {
+            files = append(files, name)
+        }
+    }
+    return files, nil
+}
+
+// readStagedBlob returns the index version of a file, which may differ from the working tree.
+func readStagedBlob(path string) ([]byte, error) {
+    out, err := exec.Command("git", "cat-file", "blob", ":"+path).Output()
+    if err != nil {
+        return nil, fmt.Errorf("failed to read staged content of %s: %v", path, err)
+    }
+    return out, nil
+}
+
+// stagedBlobSize returns the size of the index version of a file without reading it.
+func stagedBlobSize(path string) (int64, error) {
+    out, err := exec.Command("git", "cat-file", "-s", ":"+path).Output()
+    if err != nil {
+        return 0, fmt.Errorf("failed to read staged size of %s: %v", path, err)
+    }
+    return strconv.ParseInt(strings.TrimSpace(string(out)), 10, 64)
+}
+
+// workingTreeFileSize returns the size of the working-tree version of a file given relative to the repo root.
+func workingTreeFileSize(path string) (int64, error) {
+    root, err := gitRoot()
+    if err != nil {
+        return 0, err
+    }
+    info, err := os.Stat(filepath.Join(root, path))
+    if err != nil {
+        return 0, fmt.Errorf("failed to read %s: %v", path, err)
+    }
+    return info.Size(), nil
+}
+
+// readWorkingTreeFile returns the working-tree version of a file given relative to the repo root.
+func readWorkingTreeFile(path string) ([]byte, error) {
+    root, err := gitRoot()
+    if err != nil {
+        return nil, err
+    }
+    content, err := os.ReadFile(filepath.Join(root, path))
+    if err != nil {
+        return nil, fmt.Errorf("failed to read %s: %v", path, err)
+    }
+    return content, nil
+}
+
+// stagedFileTargets returns the full version of every file about to be committed:
+// the index version, or the working-tree version with workingTree. Files are read lazily.
+func stagedFileTargets(workingTree bool) ([]scanTarget, error) {
+    files, err := stagedFiles(workingTree)
+    if err != nil {
+        return nil, err
+    }
+    read, size := readStagedBlob, stagedBlobSize
+    if workingTree {
+        read, size = readWorkingTreeFile, workingTreeFileSize
+    }
+    targets := make([]scanTarget, 0, len(files))
+    for _, file := range files {
+        file := file
+        targets = append(targets, scanTarget{
+            path: file,
+            load: func() ([]byte, error) { return read(file) },
+            size: func() (int64, error) { return size(file) },
+        })
+    }
+    return targets, nil
+}
+
+// stagedSource scans the changes about to be committed: only the added lines,
+// or with fullFile the whole version of each changed file.
+func stagedSource(fullFile, workingTree bool) targetSource {
+    return func(emit func(scanTarget) error) error {
+        if fullFile {
+            targets, err := stagedFileTargets(workingTree)
+            if err != nil {
+                return err
+            }
+            return sliceSource(targets)(emit)
+        }
+        args := append([]string{"-c", "core.quotePath=false"}, stagedDiffBase(workingTree)...)
+        return streamAddedLines(append(args, addedLinesDiffArgs...), emit)
+    }
+}
+
+// addedLinesDiffArgs make "git diff" and "git log -p" print plain zero-context patches.
+var addedLinesDiffArgs = []string{
+    "-U0", "--no-color", "--no-ext-diff", "--no-textconv",
+    "--src-prefix=a/", "--dst-prefix=b/", "--diff-filter=ACMR",
+}
+
+// historySource scans the lines added by each commit selected by the "git log" revision
+// arguments, one target per file and commit.
+func historySource(revArgs []string) targetSource {
+    return func(emit func(scanTarget) error) error {
+        args := []string{"-c", "core.quotePath=false", "log", "-p", "--format=commit %H"}
+        args = append(args, addedLinesDiffArgs...)
+        args = append(args, revArgs...)
+        return streamAddedLines(append(args, "--"), emit)
+    }
+}
+
+// streamAddedLines runs a git command printing patches and emits their added lines as they are read.
+func streamAddedLines(args []string, emit func(scanTarget) error) error {
+    cmd := exec.Command("git", args...)
+    var stderr bytes.Buffer
+    cmd.Stderr = &stderr
+    stdout, err := cmd.StdoutPipe()
+    if err != nil {
+        return fmt.Errorf("failed to read changes: %v", err)
+    }
+    if err := cmd.Start(); err != nil {
+        return fmt.Errorf("failed to run git %s: %v", args[2], err)
+    }
+    if err := parseAddedLines(stdout, emit); err != nil {
+        cmd.Process.Kill()
+        cmd.Wait()
+        return err
+    }
+    if err := cmd.Wait(); err != nil {
+        return fmt.Errorf("failed to run git %s: %v: %s", args[2], err, strings.TrimSpace(stderr.String()))
+    }
+    return nil
+}
+
+// parseAddedLines extracts the added lines of zero-context patches, grouped by file,
+// from "git diff" or "git log -p --format='commit %H'" output.
+func parseAddedLines(r io.Reader, emit func(scanTarget) error) error {
+    var current *scanTarget
+    var content bytes.Buffer
+    commit := ""
+    inHeader := false
+    nextLine := 0
+
+    flush := func() error {
+        defer func() {
+            current = nil
+            content.Reset()
+        }()
+        if current != nil && len(current.lines) > 0 {
+            current.content = append([]byte(nil), content.Bytes()...)
+            return emit(*current)
+        }
+        return nil
+    }
+
+    scanner := bufio.NewScanner(r)
+    scanner.Buffer(make([]byte, 0, 64*1024), 16*1024*1024) // Max 16MB line size
+    for scanner.Scan() {
+        line := scanner.Text()
+        switch {
+        case strings.HasPrefix(line, "commit "):
+            if err := flush(); err != nil {
+                return err
+            }
+            commit = strings.TrimPrefix(line, "commit ")
+            inHeader = true
+        case strings.HasPrefix(line, "diff --git "):
+            if err := flush(); err != nil {
+                return err
+            }
+            inHeader = true
+        case inHeader && strings.HasPrefix(line, "+++ "):
+            // Git appends a tab to names containing spaces.
+            name := strings.TrimSuffix(strings.TrimPrefix(line, "+++ "), "\t")
+            if unquoted, err := strconv.Unquote(name); err == nil {
+                name = unquoted
+            }
+            if name == "/dev/null" {
+                continue
+            }
+            current = &scanTarget{path: strings.TrimPrefix(name, "b/"), commit: commit}
+        case strings.HasPrefix(line, "@@"):
+            inHeader = false
+            nextLine = hunkNewStart(line)
+        case !inHeader && current != nil && strings.HasPrefix(line, "+"):
+            content.WriteString(line[1:])
+            content.WriteByte('\n')
+            current.lines = append(current.lines, nextLine)
+            nextLine++
+        }
+    }
+    if err := scanner.Err(); err != nil {
+        return fmt.Errorf("failed to parse diff: %v", err)
+    }
+    return flush()
+}
+
+// pathSource scans the working-tree files at the given paths, walking directories.
+// Inside a repository, files are reported relative to its root so they match the baseline.
+// Directories excluded by the filter are not walked.
+func pathSource(paths []string, filter *scanFilter) targetSource {
+    return func(emit func(scanTarget) error) error {
+        return walkPaths(paths, filter, emit)
+    }
+}
+
+// walkPaths emits a lazily read target for every regular file below the paths.
+func walkPaths(paths []string, filter *scanFilter, emit func(scanTarget) error) error {
+    repoRoot, _ := gitRoot()
+    displayPath := func(path string) string {
+        if repoRoot != "" {
+            if abs, err := filepath.Abs(path); err == nil {
+                if rel, err := filepath.Rel(repoRoot, abs); err == nil && !strings.HasPrefix(rel, "..") {
+                    path = rel
+                }
+            }
+        }
+        return filepath.ToSlash(path)
+    }
+
+    for _, root := range paths {
+        err := filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+            if err != nil {
+                return err
+            }
+            if d.IsDir() {
+                if d.Name() == ".git" || (path != root && filter.skipDir(displayPath(path))) {
+                    return filepath.SkipDir
+                }
+                return nil
+            }
+            if !d.Type().IsRegular() {
+                return nil
+            }
+            info, err := d.Info()
+            if err != nil {
+                return err
+            }
+            load := func() ([]byte, error) { return os.ReadFile(path) }
+            size := func() (int64, error) { return info.Size(), nil }
+            return emit(scanTarget{path: displayPath(path), load: load, size: size})
+        })
+        if err != nil {
+            return fmt.Errorf("failed to walk %s: %v", root, err)
+        }
+    }
+    return nil
+}
+
+// hunkNewStart returns the first new-file line of a "@@ -a,b +c,d @@" hunk header.
+func hunkNewStart(header string) int {
+    fields := strings.Fields(header)
+    if len(fields) < 3 || !strings.HasPrefix(fields[2], "+") {
+        return 1
+    }
+    start := strings.SplitN(strings.TrimPrefix(fields[2], "+"), ",", 2)[0]
+    n, err := strconv.Atoi(start)
+    if err != nil {
+        return 1
+    }
+    return n
+}
+
+// trackedFiles lists the files tracked in the index.
+func trackedFiles() ([]string, error) {
+    output, err := exec.Command("git", "ls-files", "-z").Output()
+    if err != nil {
+        return nil, fmt.Errorf("failed to list tracked files: %v", err)
+    }
+    var files []string
+    for _, name := range strings.Split(string(output), "\x00") {
+        if name != "" {
+            files = append(files, name)
+        }
+    }
+    return files, nil
+}
+
+// trackedSource scans the index version of every tracked file the filter does not exclude,
+// streamed from a single "git cat-file --batch".
+func trackedSource(filter *scanFilter) targetSource {
+    return func(emit func(scanTarget) error) error {
+        files, err := trackedFiles()
+        if err != nil {
+            return err
+        }
+        var kept []string
+        for _, file := range files {
+            if !filter.skipPath(file) {
+                kept = append(kept, file)
+            }
+        }
+        return readBlobs(kept, func(path string, content []byte) error {
+            return emit(scanTarget{path: path, content: content})
+        })
+    }
+}
+
+// readBlobs reads the index version of many files through a single "git cat-file --batch".
+// Entries that are not blobs (e.g. submodules) are skipped; an error from fn stops the reading.
+func readBlobs(paths []string, fn func(path string, content []byte) error) error {
+    cmd := exec.Command("git", "cat-file", "--batch")
+    stdin, err := cmd.StdinPipe()
+    if err != nil {
+        return fmt.Errorf("failed to start git cat-file: %v", err)
+    }
+    stdout, err := cmd.StdoutPipe()
+    if err != nil {
+        return fmt.Errorf("failed to start git cat-file: %v", err)
+    }
+    if err := cmd.Start(); err != nil {
+        return fmt.Errorf("failed to start git cat-file: %v", err)
+    }
+
+    // Feed the requests concurrently so that neither side blocks on a full pipe.
+    var requested []string
+    for _, path := range paths {
+        if !strings.ContainsAny(path, "\n") {
+            requested = append(requested, path)
+        }
+    }
+    go func() {
+        w := bufio.NewWriter(stdin)
+        for _, path := range requested {
+            fmt.Fprintf(w, ":%s\n", path)
+        }
+        w.Flush()
+        stdin.Close()
+    }()
+
+    r := bufio.NewReader(stdout)
+    for _, path := range requested {
+        header, err := r.ReadString('\n')
+        if err != nil {
+            cmd.Process.Kill()
+            cmd.Wait()
+            return fmt.Errorf("failed to read %s from git cat-file: %v", path, err)
+        }
+        // "<oid> <type> <size>" or "<object> missing".
+        fields := strings.Fields(header)
+        if len(fields) != 3 {
+            continue
+        }
+        size, err := strconv.Atoi(fields[2])
+        if err != nil {
+            continue
+        }
+        content := make([]byte, size+1) // Content is followed by a newline
+        if _, err := io.ReadFull(r, content); err != nil {
+            cmd.Process.Kill()
+            cmd.Wait()
+            return fmt.Errorf("failed to read %s from git cat-file: %v", path, err)
+        }
+        if fields[1] == "blob" {
+            if err := fn(path, content[:size]); err != nil {
+                cmd.Process.Kill()
+                cmd.Wait()
+                return err
+            }
+        }
+    }
+    if err := cmd.Wait(); err != nil {
+        return fmt.Errorf("git cat-file failed: %v", err)
+    }
+    return nil
+}
@@ -0,0 +1,119 @@
+package internal
+
+import (
+    "reflect"
+    "strings"
+    "testing"
+)
+
+func TestParseAddedLines(t *testing.T) {
+    tests := []struct {
+        name  string
+        patch string
+        want  []scanTarget
+    }{
+        {
+            name: "staged diff",
+            patch: `diff --git a/config.env b/config.env
+index 1111111..2222222 100644
+--- a/config.env
++++ b/config.env
+@@ -2,0 +3,2 @@ HOST=localhost
++USER=admin
++TOKEN=abc
+@@ -10 +12 @@ PORT=80
+-OLD=1
+++++ added line that starts with plus signs
+diff --git a/gone.txt b/gone.txt
+deleted file mode 100644
+--- a/gone.txt
++++ /dev/null
+@@ -1 +0,0 @@
+-removed
+diff --git a/new file.txt b/new file.txt
+new file mode 100644
+--- /dev/null
++++ b/new file.txt
+@@ -0,0 +1 @@
++hello
+`,
+            want: []scanTarget{
+                {path: "config.env", content: []byte("USER=admin\nTOKEN=abc\n+++ added line that starts with plus signs\n"), lines: []int{3, 4, 12}},
+                {path: "new file.txt", content: []byte("hello\n"), lines: []int{1}},
+            },
+        },
+        {
+            name: "log patches",
+            patch: `commit 2222222222222222222222222222222222222222
+
+diff --git a/a.env b/a.env
+--- a/a.env
++++ b/a.env
+@@ -1 +1 @@
+-A=1
++A=2
+commit 1111111111111111111111111111111111111111
+
+diff --git a/a.env b/a.env
+new file mode 100644
+--- /dev/null
++++ b/a.env
+@@ -0,0 +1 @@
++A=1
+diff --git "a/caf\303\251.txt" "b/caf\303\251.txt"
+new file mode 100644
+--- /dev/null
++++ "b/caf\303\251.txt"
+@@ -0,0 +1 @@
++menu
+`,
+            want: []scanTarget{
+                {path: "a.env", commit: strings.Repeat("2", 40), content: []byte("A=2\n"), lines: []int{1}},
+                {path: "a.env", commit: strings.Repeat("1", 40), content: []byte("A=1\n"), lines: []int{1}},
+                {path: "café.txt", commit: strings.Repeat("1", 40), content: []byte("menu\n"), lines: []int{1}},
+            },
+        },
+        {
+            name: "commit without changes",
+            patch: `commit 3333333333333333333333333333333333333333
+`,
+        },
+    }
+
+    for _, tt := range tests {
+        t.Run(tt.name, func(t *testing.T) {
+            var got []scanTarget
+            err := parseAddedLines(strings.NewReader(tt.patch), func(target scanTarget) error {
+                got = append(got, target)
+                return nil
+            })
+            if err != nil {
+                t.Fatalf("parseAddedLines() error = %v", err)
+            }
+            if !reflect.DeepEqual(got, tt.want) {
+                t.Errorf("parseAddedLines() = %+v, want %+v", got, tt.want)
+            }
+        })
+    }
+}
+
+func TestHunkNewStart(t *testing.T) {
+    tests := []struct {
+        header string
+        want   int
+    }{
+        {"@@ -2,0 +3,2 @@ HOST=localhost", 3},
+        {"@@ -10 +12 @@", 12},
+        {"@@ -0,0 +1 @@", 1},
+        {"@@@ -1 -1 +1 @@@", 1},
+        {"@@ malformed", 1},
+    }
+
+    for _, tt := range tests {
+        t.Run(tt.header, func(t *testing.T) {
+            if got := hunkNewStart(tt.header); got != tt.want {
+                t.Errorf("hunkNewStart(%q) = %d, want %d", tt.header, got, tt.want)
+            }
+        })
+    }
+}
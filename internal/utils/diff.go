@@ -0,0 +1,220 @@
+package utils
+
+import (
+    "fmt"
+    "strings"
+)
+
+// diffOp is one line of an edit script: ' ' keeps, '-' deletes and '+' inserts a line.
+type diffOp struct {
+    kind byte
+    text string
+}
+
+// diffLines computes the shortest edit script turning a into b with Myers' linear-space
+// algorithm: the middle snake of the edit graph splits the problem in two halves, so memory
+// stays proportional to the input size however different the texts are.
+func diffLines(a, b []string) []diffOp {
+    d := &differ{a: a, b: b}
+    d.compare(0, len(a), 0, len(b))
+    return d.ops
+}
+
+// differ holds the inputs and the edit script built so far.
+type differ struct {
+    a, b []string
+    ops  []diffOp
+}
+
+// compare appends the edit script turning a[a0:a1] into b[b0:b1].
+func (d *differ) compare(a0, a1, b0, b1 int) {
+    for a0 < a1 && b0 < b1 && d.a[a0] == d.b[b0] {
+        d.ops = append(d.ops, diffOp{' ', d.a[a0]})
+        a0++
+        b0++
+    }
+    suffix := a1
+    for a1 > a0 && b1 > b0 && d.a[a1-1] == d.b[b1-1] {
+        a1--
+        b1--
+    }
+
+    switch {
+    case a0 == a1:
+        for _, line := range d.b[b0:b1] {
+            d.ops = append(d.ops, diffOp{'+', line})
+        }
+    case b0 == b1:
+        for _, line := range d.a[a0:a1] {
+            d.ops = append(d.ops, diffOp{'-', line})
+        }
+    default:
+        x, y := d.middleSnake(a0, a1, b0, b1)
+        d.compare(a0, x, b0, y)
+        d.compare(x, a1, y, b1)
+    }
+
+    for _, line := range d.a[a1:suffix] {
+        d.ops = append(d.ops, diffOp{' ', line})
+    }
+}
+
+// middleSnake returns a point on a shortest edit path from (a0, b0) to (a1, b1), found where the
+// forward and backward searches meet. The ranges are non-empty and differ in their first and
+// last lines, so the point is strictly inside and both halves are smaller.
+func (d *differ) middleSnake(a0, a1, b0, b1 int) (int, int) {
+    n, m := a1-a0, b1-b0
+    delta := n - m
+    max := (n + m + 1) / 2
+    offset := max + 1
+    // vf[offset+k] is the furthest x reached on diagonal k = x - y from the start; vb is the
+    // same for the backward search, in coordinates counted from the end.
+    vf := make([]int, 2*max+3)
+    vb := make([]int, 2*max+3)
+
+    for D := 0; D <= max; D++ {
+        for k := -D; k <= D; k += 2 {
+            var x int
+            if k == -D || (k != D && vf[offset+k-1] < vf[offset+k+1]) {
+                x = vf[offset+k+1]
+            } else {
+                x = vf[offset+k-1] + 1
+            }
+            y := x - k
+            for x < n && y < m && d.a[a0+x] == d.b[b0+y] {
+                x++
+                y++
+            }
+            vf[offset+k] = x
+            if kb := delta - k; delta%2 != 0 && kb >= -(D-1) && kb <= D-1 && x+vb[offset+kb] >= n {
+                return a0 + x, b0 + y
+            }
+        }
+        for k := -D; k <= D; k += 2 {
+            var x int
+            if k == -D || (k != D && vb[offset+k-1] < vb[offset+k+1]) {
+                x = vb[offset+k+1]
+            } else {
+                x = vb[offset+k-1] + 1
+            }
+            y := x - k
+            for x < n && y < m && d.a[a1-1-x] == d.b[b1-1-y] {
+                x++
+                y++
+            }
+            vb[offset+k] = x
+            if kf := delta - k; delta%2 == 0 && kf >= -D && kf <= D && x+vf[offset+kf] >= n {
+                return a1 - x, b1 - y
+            }
+        }
+    }
+    // Unreachable: the searches meet after at most n+m edits.
+    return a0, b0
+}
+
+// UnifiedDiff returns a unified diff (3 lines of context) between two texts,
+// or an empty string if they are equal.
+func UnifiedDiff(fromName, toName, a, b string) string {
+    if a == b {
+        return ""
+    }
+    ops := diffLines(splitDiffLines(a), splitDiffLines(b))
+
+    // Line positions (0-based) in a and b before each op.
+    aPos := make([]int, len(ops)+1)
+    bPos := make([]int, len(ops)+1)
+    for i, op := range ops {
+        aPos[i+1], bPos[i+1] = aPos[i], bPos[i]
+        if op.kind != '+' {
+            aPos[i+1]++
+        }
+        if op.kind != '-' {
+            bPos[i+1]++
+        }
+    }
+
+    const context = 3
+    var buf strings.Builder
+    fmt.Fprintf(&buf, "--- %s\n+++ %s\n", fromName, toName)
+
+    for i := 0; i < len(ops); {
+        if ops[i].kind == ' ' {
+            i++
+            continue
+        }
+
+        // Extend the hunk while changes are close enough to share context.
+        start := i - context
+        if start < 0 {
+            start = 0
+        }
+        end := i
+        for j := i; j < len(ops); j++ {
+            if ops[j].kind != ' ' {
+                end = j + 1
+            } else if j-end >= 2*context {
+                break
+            }
+        }
+        stop := end + context
+        if stop > len(ops) {
+            stop = len(ops)
+        }
+
+        aLen, bLen := aPos[stop]-aPos[start], bPos[stop]-bPos[start]
+        fmt.Fprintf(&buf, "@@ -%s +%s @@\n", hunkRange(aPos[start], aLen), hunkRange(bPos[start], bLen))
+        for _, op := range ops[start:stop] {
+            buf.WriteByte(op.kind)
+            buf.WriteString(op.text)
+            buf.WriteByte('\n')
+        }
+        i = stop
+    }
+    return buf.String()
+}
+
+// ColorDiff colors the added and removed lines of a unified diff.
+func ColorDiff(diff string) string {
+    lines := strings.Split(strings.TrimSuffix(diff, "\n"), "\n")
+    for i, line := range lines {
+        switch {
+        case strings.HasPrefix(line, "+++"), strings.HasPrefix(line, "---"):
+            lines[i] = Bold(line)
+        case strings.HasPrefix(line, "@@"):
+            lines[i] = Color(line, "cyan")
+        case strings.HasPrefix(line, "+"):
+            lines[i] = Color(line, "green")
+        case strings.HasPrefix(line, "-"):
+            lines[i] = Color(line, "red")
+        }
+    }
+    return strings.Join(lines, "\n") + "\n"
+}
+
+// StdoutDiff colors a diff with ColorDiff when stdout is a terminal, and returns it unchanged
+// otherwise, so that pipes and hook logs get no escape codes.
+func StdoutDiff(diff string) string {
+    if diff == "" || !IsStdoutTerminal() {
+        return diff
+    }
+    return ColorDiff(diff)
+}
+
+// splitDiffLines splits text into lines, ignoring a single trailing newline.
+func splitDiffLines(s string) []string {
+    if s == "" {
+        return nil
+    }
+    return strings.Split(strings.TrimSuffix(s, "\n"), "\n")
+}
+
+// hunkRange formats a "start,length" range for a hunk header.
+func hunkRange(start, length int) string {
+    if length == 0 {
+        return fmt.Sprintf("%d,0", start)
+    }
+    if length == 1 {
+        return fmt.Sprintf("%d", start+1)
+    }
+    return fmt.Sprintf("%d,%d", start+1, length)
+}
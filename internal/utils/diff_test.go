@@ -0,0 +1,110 @@
+package utils
+
+import (
+    "fmt"
+    "strings"
+    "testing"
+)
+
+func TestUnifiedDiff(t *testing.T) {
+    tests := []struct {
+        name string
+        a, b string
+        want string
+    }{
+        {"equal", "a\nb\n", "a\nb\n", ""},
+        {
+            "changed line",
+            "a\nb\nc\n", "a\nB\nc\n",
+            "--- old\n+++ new\n@@ -1,3 +1,3 @@\n a\n-b\n+B\n c\n",
+        },
+        {
+            "from empty",
+            "", "a\nb\n",
+            "--- old\n+++ new\n@@ -0,0 +1,2 @@\n+a\n+b\n",
+        },
+        {
+            "to empty",
+            "a\n", "",
+            "--- old\n+++ new\n@@ -1 +0,0 @@\n-a\n",
+        },
+        {
+            "separate hunks",
+            "1\n2\n3\n4\n5\n6\n7\n8\n9\n10\n11\n12\n", "x\n2\n3\n4\n5\n6\n7\n8\n9\n10\n11\ny\n",
+            "--- old\n+++ new\n@@ -1,4 +1,4 @@\n-1\n+x\n 2\n 3\n 4\n@@ -9,4 +9,4 @@\n 9\n 10\n 11\n-12\n+y\n",
+        },
+        {
+            "close changes share a hunk",
+            "1\n2\n3\n4\n5\n6\n", "x\n2\n3\n4\n5\ny\n",
+            "--- old\n+++ new\n@@ -1,6 +1,6 @@\n-1\n+x\n 2\n 3\n 4\n 5\n-6\n+y\n",
+        },
+    }
+    for _, tt := range tests {
+        t.Run(tt.name, func(t *testing.T) {
+            if got := UnifiedDiff("old", "new", tt.a, tt.b); got != tt.want {
+                t.Errorf("got:\n%s\nwant:\n%s", got, tt.want)
+            }
+        })
+    }
+}
+
+func TestColorDiff(t *testing.T) {
+    diff := "--- a\n+++ b\n@@ -1 +1 @@\n-old\n+new\n same\n"
+    want := Bold("--- a") + "\n" + Bold("+++ b") + "\n" + Color("@@ -1 +1 @@", "cyan") + "\n" +
+        Color("-old", "red") + "\n" + Color("+new", "green") + "\n same\n"
+    if got := ColorDiff(diff); got != want {
+        t.Errorf("got %q, want %q", got, want)
+    }
+}
+
+func TestDiffLines(t *testing.T) {
+    tests := []struct {
+        a, b  string
+        edits int
+    }{
+        {"", "", 0},
+        {"abc", "abc", 0},
+        {"abc", "", 3},
+        {"", "abc", 3},
+        {"abcabba", "cbabac", 5},
+        {"xaxbxc", "abc", 3},
+        {"abcd", "dcba", 6},
+        {"aaaa", "aa", 2},
+    }
+    for _, tt := range tests {
+        t.Run(tt.a+" "+tt.b, func(t *testing.T) {
+            a, b := strings.Split(tt.a, ""), strings.Split(tt.b, "")
+            ops := diffLines(a, b)
+            edits, gotA, gotB := 0, []string{}, []string{}
+            for _, op := range ops {
+                if op.kind != ' ' {
+                    edits++
+                }
+                if op.kind != '+' {
+                    gotA = append(gotA, op.text)
+                }
+                if op.kind != '-' {
+                    gotB = append(gotB, op.text)
+                }
+            }
+            if strings.Join(gotA, "") != tt.a || strings.Join(gotB, "") != tt.b {
+                t.Errorf("script turns %q into %q, want %q into %q", strings.Join(gotA, ""), strings.Join(gotB, ""), tt.a, tt.b)
+            }
+            if edits != tt.edits {
+                t.Errorf("%d edits, want %d", edits, tt.edits)
+            }
+        })
+    }
+}
+
+func TestDiffLinesDisjoint(t *testing.T) {
+    // Entirely different inputs are the worst case for memory.
+    const n = 6000
+    a, b := make([]string, n), make([]string, n)
+    for i := range a {
+        a[i], b[i] = fmt.Sprint("a", i), fmt.Sprint("b", i)
+    }
+    if ops := diffLines(a, b); len(ops) != 2*n {
+        t.Errorf("%d ops, want %d", len(ops), 2*n)
+    }
+}
@@ -46,6 +46,15 @@ func isTerminal() bool {
     return (fi.Mode() & os.ModeCharDevice) != 0
 }
 
+// IsStdoutTerminal checks if stdout is a terminal, so that colors are only written to one.
+func IsStdoutTerminal() bool {
+    fi, err := os.Stdout.Stat()
+    if err != nil {
+        return false
+    }
+    return (fi.Mode() & os.ModeCharDevice) != 0
+}
+
 // ========================
 // Terminal UI Struct
 // ========================
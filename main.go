@@ -1,8 +1,10 @@
 package main
 
 import (
+    "context"
     "fmt"
     "os"
+    "os/signal"
 
     "gommitizen/cmd"
     "gommitizen/internal"
@@ -38,8 +40,39 @@ func main() {
     case "commit":
         internal.CommitCommand(commandArgs)
     case "changelog":
-        if err := internal.GenerateChangelog(); err != nil {
-            fmt.Printf("Changelog generation failed: %v\n", err)
+        opts, err := cmd.ParseChangelogOptions(commandArgs)
+        if err != nil {
+            fmt.Println("Failed to parse changelog flags:", err)
+            os.Exit(1)
+        }
+        if err := internal.GenerateChangelog(internal.ChangelogOptions{
+            Incremental: opts.Incremental,
+            Format:      opts.Format,
+            Template:    opts.Template,
+            From:        opts.From,
+            To:          opts.To,
+            Since:       opts.Since,
+            Unreleased:  opts.Unreleased,
+            Output:      opts.Output,
+            Stdout:      opts.Stdout,
+
+            NoFilter:       opts.NoFilter,
+            IncludeTypes:   opts.IncludeTypes,
+            ExcludeTypes:   opts.ExcludeTypes,
+            IncludeMerges:  opts.IncludeMerges,
+            ExcludeAuthors: opts.ExcludeAuthors,
+            ExcludeHeaders: opts.ExcludeHeaders,
+            ShowReverted:   opts.ShowReverted,
+
+            Description: opts.Description,
+            Check:       opts.Check,
+        }); err != nil {
+            if opts.Check {
+                fmt.Fprintf(os.Stderr, "Changelog check failed: %v\n", err)
+            } else {
+                fmt.Fprintf(os.Stderr, "Changelog generation failed: %v\n", err)
+            }
+            os.Exit(1)
         }
     case "bump":
         if newVersion, err := cmd.BumpVersion(); err != nil {
@@ -47,6 +80,39 @@ func main() {
         } else {
             fmt.Printf("New version: %s\n", newVersion)
         }
+    case "scan":
+        // Exit codes: 0 nothing found, 1 findings, 2 errors.
+        opts, err := cmd.ParseScanOptions(commandArgs)
+        if err != nil {
+            fmt.Println("Failed to parse scan flags:", err)
+            os.Exit(2)
+        }
+        ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+        count, err := internal.ScanSecrets(ctx, internal.ScanOptions{
+            FullFile:   opts.FullFile,
+            Range:      opts.Range,
+            AllHistory: opts.AllHistory,
+            Paths:      opts.Paths,
+            Format:     opts.Format,
+            NoBaseline: opts.NoBaseline,
+        }, os.Stdout)
+        stop()
+        if err != nil {
+            fmt.Fprintf(os.Stderr, "Scan failed: %v\n", err)
+            os.Exit(2)
+        }
+        if count > 0 {
+            os.Exit(1)
+        }
+    case "secrets":
+        if len(commandArgs) == 0 || commandArgs[0] != "baseline" {
+            fmt.Println("Usage: git-cz secrets baseline")
+            os.Exit(1)
+        }
+        if err := internal.UpdateSecretsBaseline(); err != nil {
+            fmt.Printf("Secrets baseline update failed: %v\n", err)
+            os.Exit(1)
+        }
     case "lint":
         // Note: Lint options parsing expects args after "lint"
         opts, err := cmd.ParseLintOptions(commandArgs)
@@ -55,8 +121,30 @@ func main() {
             os.Exit(1)
         }
 
-        if opts.All {
-            err := internal.LintAllCommitMessage()
+        if opts.Fix {
+            var err error
+            switch {
+            case opts.Current:
+                err = internal.FixCurrentCommitMessage(opts.Yes)
+            case opts.File != "":
+                err = internal.FixMessageFile(opts.File)
+            case opts.Message != "":
+                var fixed string
+                fixed, err = internal.FixSingleMessage(opts.Message)
+                fmt.Println(fixed)
+            default:
+                fmt.Println("No lint target specified. Use --fix with --current, --file, or provide a message.")
+                os.Exit(1)
+            }
+            if err != nil {
+                fmt.Println(err.Error())
+                os.Exit(1)
+            }
+        } else if opts.All {
+            err := internal.LintAllCommitMessage(internal.LintAllOptions{
+                MaxCount: opts.MaxCount,
+                Progress: opts.Progress,
+            })
             if err != nil {
                 fmt.Println(err.Error())
                 os.Exit(1)
@@ -69,6 +157,13 @@ func main() {
                 os.Exit(1)
             }
             fmt.Println(utils.Color("Current commit message passes linting.", "green"))
+        } else if opts.File != "" {
+            err := internal.LintMessageFile(opts.File)
+            if err != nil {
+                fmt.Println(err.Error())
+                os.Exit(1)
+            }
+            fmt.Println(utils.Color("Commit message file passes linting.", "green"))
         } else if opts.Message != "" {
             err := internal.LintSingleMessage(opts.Message)
             if err != nil {
@@ -77,7 +172,7 @@ func main() {
             }
             fmt.Println(utils.Color("Provided message passes linting.", "green"))
         } else {
-            fmt.Println("No lint target specified. Use --all, --current, or provide a message.")
+            fmt.Println("No lint target specified. Use --all, --current, --file, or provide a message.")
             os.Exit(1)
         }
     default: